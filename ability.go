@@ -32,6 +32,8 @@ type SpellAbility struct {
 type ActivatedAbility struct {
 	ability
 	cost cost
+	// 'activate only as a sorcery', i.e. 702.6a equip
+	sorcerySpeed bool
 }
 
 // TODO: 605.1a An activated ability is a mana ability if it meets the following criteria:
@@ -39,24 +41,30 @@ type ActivatedAbility struct {
 // - it could add mana to a player's mana pool when it resolves
 // - it is not a loyalty ability
 func (aa ActivatedAbility) isManaAbility() bool {
-	if len(aa.targets) == 0 {
-		return true
+	for _, t := range aa.targets {
+		if !t.isUntargeted() {
+			return false
+		}
 	}
-	noTarget := len(aa.targets) == 1 && aa.targets[0].isUntargeted()
 	_, addsMana := aa.getEffect().(addMana)
-	return noTarget && addsMana // && noLoyalty
+	return addsMana && !aa.cost.loyaltyAbility
 }
 
 type TriggeredAbility struct {
 	ability
-	//trigger trigger
+	trigger trigger
 }
 
 // TODO 605.1b similar for triggered abilities
 
+// 604.1 Static abilities do something all the time rather than being activated or triggered.
 type StaticAbility struct {
-	ability
-	// ???
+	effect *continuousEffect
+	// 'spells you cast cost {1} less', optionally only matching costFilter
+	costReduction int
+	costFilter    func(Card) bool
+	// 614.1c 'if [event] would [...], instead [...]'
+	replacement *replacement
 }
 
 type targetType int
@@ -65,12 +73,44 @@ const (
 	you targetType = iota
 	targetPlayer
 	eachPlayer
+	eachOpponent
+	targetSpell
+	targetCreature
+	targetPermanent
+	// 115.4 any target: a creature, player, planeswalker or battle
+	anyTarget
+	// 'target creature you control'
+	targetCreatureYouControl
+	// 'target player or planeswalker'
+	targetPlayerOrPlaneswalker
 )
 
 func (t targetType) isPlayer() bool {
-	return t == you || t == targetPlayer || t == eachPlayer
+	return t == you || t == targetPlayer || t == eachPlayer || t == eachOpponent
 }
 
 func (t targetType) isUntargeted() bool {
-	return t == you || t == eachPlayer
+	return t == you || t == eachPlayer || t == eachOpponent
+}
+
+// 702.1 Keyword abilities, as a bitset on creatures. Most of them are static abilities
+// that change the rules of combat, see combatDamage and isLegalBlock.
+type keyword uint16
+
+const (
+	flying keyword = 1 << iota
+	reach
+	firstStrike
+	doubleStrike
+	trample
+	deathtouch
+	lifelink
+	vigilance
+	haste
+	menace
+	infect
+)
+
+func (k keyword) has(kw keyword) bool {
+	return k&kw != 0
 }
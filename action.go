@@ -18,36 +18,132 @@ type passAction struct {
 	action
 }
 
-// TODO: similarly, activated abilities & triggers etc
+// 405.1 spells and abilities wait on the stack to resolve
+type stackObject interface {
+	Action
+	getID() uint64
+	getName() string
+	getTargets() []effectTarget
+	resolve(g *game)
+}
+
 type cardAction struct {
 	action
 	card Card
+	// id: assigned when the spell is put on the stack, see stackObject.getID
+	id uint64
 	// targets: used for casting spells with a target
 	// i.e. instants and sorceries with spell abilities
-	// index: in relevant zone(s), as per ability target type(s)
 	targets []effectTarget
+	// fromCommandZone: 903.8 a commander is cast from the command zone instead of from hand
+	fromCommandZone bool
+}
+
+// 400.7 a spell or ability put on the stack is a new object: its id stays the same while it is
+// on the stack, so that targets stay valid when objects below it leave the stack
+func (a cardAction) getID() uint64 {
+	return a.id
+}
+
+func (a cardAction) getName() string {
+	return a.card.getName()
+}
+
+func (a cardAction) getTargets() []effectTarget {
+	return a.targets
 }
 
+func (a cardAction) resolve(g *game) {
+	a.card.resolve(g, a)
+}
+
+// 116.2a Playing a land is a special action. To play a land, a player puts that land onto
+// the battlefield from the zone it was in (usually that player's hand).
+// 305.1 [...] Playing a land doesn't use the stack.
+type playLandAction struct {
+	action
+	card Card
+}
+
+// 602.2 To activate an ability is to put it onto the stack and pay its costs
+type activateAction struct {
+	action
+	// id: cardInstance.id of the source permanent
+	id uint64
+	// index: in source card.activatedAbilities
+	index   int
+	targets []effectTarget
+}
+
+// 113.7 An ability on the stack is independent of its source:
+// it resolves even if the source has left the battlefield
+type abilityOnStack struct {
+	action
+	// id: assigned when the ability is put on the stack, see stackObject.getID
+	id     uint64
+	source Card
+	// sourceID: cardInstance.id of the permanent the ability originates from
+	sourceID uint64
+	ability  Ability
+	targets  []effectTarget
+}
+
+func (a abilityOnStack) getID() uint64 {
+	return a.id
+}
+
+func (a abilityOnStack) getName() string {
+	return a.source.getName() + " ability"
+}
+
+func (a abilityOnStack) getTargets() []effectTarget {
+	return a.targets
+}
+
+func (a abilityOnStack) resolve(g *game) {
+	targets := g.legalTargets(a.controller, a.targets)
+	if len(targets) == 0 && len(a.targets) != 0 {
+		return
+	}
+	effect := a.ability.getEffect()
+	if se, ok := effect.(sourcedEffect); ok {
+		effect = se.withSource(a.sourceID)
+	}
+	effect.apply(g, targets)
+}
+
+// permanents are targeted by cardInstance.id and spells by their id on the stack,
+// so that targets stay valid when the battlefield or the stack changes; players by index
 type effectTarget struct {
+	// index: in game.players, as per ability target type(s)
 	index target
+	// id: cardInstance.id of a targeted permanent, or stackObject.getID of a targeted spell
+	id    uint64
 	ttype targetType
 }
 
+func (t effectTarget) isPermanent() bool {
+	return t.id != 0 && t.ttype != targetSpell
+}
+
 type attackAction struct {
 	action
-	// index:  in controller.battlefield
+	// id:     of attacking creature in controller.battlefield
 	// target: in game.players
 	attackers []combatTarget
 }
 
 type blockAction struct {
 	action
-	// index:  in controller.battlefield
-	// target: in activeplayer.battlefield
+	// id:     of blocking creature in controller.battlefield
+	// blocks: id of attacking creature in activeplayer.battlefield
 	blockers []combatTarget
 }
 
 type combatTarget struct {
-	index  int
+	id     uint64
 	target int
+	// planeswalker: id of the planeswalker controlled by target being attacked, if any
+	planeswalker uint64
+	blocks       uint64
 }
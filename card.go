@@ -23,12 +23,19 @@ type Card interface {
 	getManaCost() mana
 	getPrereqs() []prerequisiteFunc
 	getActivatedAbilities() []ActivatedAbility
+	getTriggeredAbilities() []TriggeredAbility
+	getStaticAbilities() []StaticAbility
+	isLegendary() bool
+	isToken() bool
 }
 
 type card struct {
-	name     string
-	manaCost mana
-	prereqs  []prerequisiteFunc
+	name      string
+	manaCost  mana
+	legendary bool
+	// 111.1 A token is a marker used to represent any permanent that isn't represented by a card.
+	token   bool
+	prereqs []prerequisiteFunc
 	// abilities
 	activatedAbilities []ActivatedAbility
 	triggeredAbilities []TriggeredAbility
@@ -38,11 +45,62 @@ type card struct {
 // the token of type card, i.e. a specific Mountain
 type cardInstance struct {
 	// unique id for targetting etc
-	id                uint64
-	card              Card
+	id   uint64
+	card Card
+	// owner: index in game.players; 108.3 the owner of a card is the player who started
+	// the game with it in their deck, the controller is the player whose battlefield it is on
+	owner             int
 	tapped            bool
 	summoningSickness bool
-	attacking         int
+	// attacking: index in game.players, -1 if not attacking
+	attacking int
+	// attackingPlaneswalker: id of the planeswalker controlled by that player
+	// this creature is attacking, 0 if it is attacking the player
+	attackingPlaneswalker uint64
+	// blocking: id of the attacker in activeplayer.battlefield, 0 if not blocking
+	blocking uint64
+	// blocked: attacker remains blocked even if its blockers are removed (509.1h)
+	blocked bool
+	// damage marked on this permanent, removed during cleanup (514.2)
+	damage int
+	// 704.5h dealt damage by a source with deathtouch since the last state-based action check
+	deathtouched bool
+	// attachedTo: id of the permanent this aura or equipment is attached to
+	attachedTo uint64
+	counters   map[counterType]int
+	// 606.3 a loyalty ability of this permanent has been activated this turn
+	loyaltyActivated bool
+	// 613.7d A permanent receives a timestamp at the time it entered the battlefield.
+	timestamp int
+}
+
+type counterType int
+
+const (
+	plusOneCounter counterType = iota
+	minusOneCounter
+	loyaltyCounter
+	poisonCounter
+	// generic named counters without rules meaning of their own, i.e. charge counters
+	chargeCounter
+)
+
+// 122.1 A counter is a marker placed on an object or player that modifies its
+// characteristics and/or interacts with a rule, ability, or effect.
+// removing more counters than there are leaves none.
+func (c *cardInstance) addCounters(ct counterType, n int) {
+	c.counters = addCounters(c.counters, ct, n)
+}
+
+func addCounters(counters map[counterType]int, ct counterType, n int) map[counterType]int {
+	if counters == nil {
+		counters = map[counterType]int{}
+	}
+	counters[ct] += n
+	if counters[ct] <= 0 {
+		delete(counters, ct)
+	}
+	return counters
 }
 
 func instanceOf(c Card) cardInstance {
@@ -93,9 +151,32 @@ func (m mana) covers(n mana) bool {
 	return m.converted() >= n.c
 }
 
+// pay spends n from m: colored mana first, then generic mana from whatever is left,
+// colorless first. assumption: m covers n
+func (m mana) pay(n mana) mana {
+	m = m.sub(mana{w: n.w, u: n.u, b: n.b, r: n.r, g: n.g})
+	generic := n.c
+	for _, amount := range []*int{&m.c, &m.w, &m.u, &m.b, &m.r, &m.g} {
+		spent := generic
+		if *amount < spent {
+			spent = *amount
+		}
+		*amount -= spent
+		generic -= spent
+	}
+	return m
+}
+
 type cost struct {
-	mana mana
-	tap  bool
+	mana      mana
+	tap       bool
+	sacrifice bool
+	life      int
+	// 606.4 The cost to activate a loyalty ability of a permanent is to put on or remove
+	// from that permanent a certain number of loyalty counters
+	loyalty int
+	// loyaltyAbility: set for loyalty abilities, since their loyalty cost can be +0
+	loyaltyAbility bool
 	// alternative costs
 }
 
@@ -117,6 +198,75 @@ func (c card) getActivatedAbilities() []ActivatedAbility {
 	return c.activatedAbilities
 }
 
+func (c card) getTriggeredAbilities() []TriggeredAbility {
+	return c.triggeredAbilities
+}
+
+func (c card) getStaticAbilities() []StaticAbility {
+	return c.staticAbilities
+}
+
+func (c card) isLegendary() bool {
+	return c.legendary
+}
+
+func (c card) isToken() bool {
+	return c.token
+}
+
+// 707.2 When copying an object, the copy acquires the copiable values of the original
+// object's characteristics: the values printed on it, not counters or other effects.
+// A token created as a copy of a card is a token with those copiable values.
+func tokenCopy(c Card) Card {
+	switch t := c.(type) {
+	case *creature:
+		cp := *t
+		cp.token = true
+		return &cp
+	case *artifact:
+		cp := *t
+		cp.token = true
+		return &cp
+	case *equipment:
+		cp := *t
+		cp.token = true
+		return &cp
+	case *enchantment:
+		cp := *t
+		cp.token = true
+		return &cp
+	case *planeswalker:
+		cp := *t
+		cp.token = true
+		return &cp
+	}
+	panic(fmt.Sprintf("cannot copy %s as a token", c.getName()))
+}
+
+// instants and sorceries have a spell ability that is followed as instructions
+// while they resolve, after which they go to the graveyard
+type spell interface {
+	Card
+	getSpellAbility() SpellAbility
+}
+
+// 608.2b If the spell or ability specifies targets, it checks whether the targets
+// are still legal. [...] The spell or ability is countered if all its targets are illegal.
+// Otherwise it resolves, but does not affect the illegal targets.
+func resolveSpell(g *game, a cardAction, s spell) {
+	targets := g.legalTargets(a.controller, a.targets)
+	if len(targets) > 0 || len(a.targets) == 0 {
+		f := s.getSpellAbility().getEffect()
+		f.apply(g, targets)
+	}
+	g.moveCard(cardInstance{card: s, owner: a.controller}, zoneStack, zoneGraveyard)
+}
+
+func isInstantOrSorcery(c Card) bool {
+	_, ok := c.(spell)
+	return ok
+}
+
 type sorcery struct {
 	card
 	spellAbility SpellAbility
@@ -127,14 +277,39 @@ func (s *sorcery) prereq(g *game, pindex int) bool {
 }
 
 func (s *sorcery) resolve(g *game, a cardAction) {
-	p := g.getPlayer(a.controller)
-	f := s.spellAbility.getEffect()
-	f.apply(g, a.targets)
-	p.graveyard = append(p.graveyard, s)
+	resolveSpell(g, a, s)
+}
+
+func (s *sorcery) getSpellAbility() SpellAbility {
+	return s.spellAbility
+}
+
+type instant struct {
+	card
+	spellAbility SpellAbility
+}
+
+func (i *instant) prereq(g *game, pindex int) bool {
+	return instantSpeed(g, pindex)
+}
+
+func (i *instant) resolve(g *game, a cardAction) {
+	resolveSpell(g, a, i)
+}
+
+func (i *instant) getSpellAbility() SpellAbility {
+	return i.spellAbility
 }
 
 type land struct {
 	card
+	// 205.4c Any land with the supertype "basic" is a basic land.
+	basic bool
+}
+
+func isBasicLand(c Card) bool {
+	l, ok := c.(*land)
+	return ok && l.basic
 }
 
 func (l *land) prereq(g *game, pindex int) bool {
@@ -145,16 +320,16 @@ func (l *land) prereq(g *game, pindex int) bool {
 	return !p.landPlayed
 }
 
+// 305.1 lands are never put on the stack, see playLand
 func (l *land) resolve(g *game, a cardAction) {
-	p := g.getPlayer(a.controller)
-	p.landPlayed = true
-	p.battlefield.lands = append(p.battlefield.lands, instanceOf(l))
+	panic("lands don't use the stack")
 }
 
 type creature struct {
 	card
 	power     int
 	toughness int
+	keywords  keyword
 }
 
 func (c *creature) prereq(g *game, pindex int) bool {
@@ -162,17 +337,105 @@ func (c *creature) prereq(g *game, pindex int) bool {
 }
 
 func (c *creature) resolve(g *game, a cardAction) {
-	p := g.getPlayer(a.controller)
-	instance := instanceOf(c)
-	instance.attacking = -1
-	instance.summoningSickness = true
-	p.battlefield.creatures = append(p.battlefield.creatures, instance)
+	g.moveCard(cardInstance{card: c, owner: a.controller}, zoneStack, zoneBattlefield)
+}
+
+// 303.1 Enchantment
+type enchantment struct {
+	card
+}
+
+func (e *enchantment) prereq(g *game, pindex int) bool {
+	return sorcerySpeed(g, pindex)
+}
+
+func (e *enchantment) resolve(g *game, a cardAction) {
+	g.moveCard(cardInstance{card: e, owner: a.controller}, zoneStack, zoneBattlefield)
+}
+
+// 303.4a An Aura spell requires a target, which is defined by its enchant ability.
+type aura struct {
+	card
+	enchant targetType
 }
 
+func (e *aura) prereq(g *game, pindex int) bool {
+	return sorcerySpeed(g, pindex)
+}
+
+func (e *aura) enchantAbility() Ability {
+	return SpellAbility{ability{targets: []targetType{e.enchant}}}
+}
+
+// 608.3b If the object that's resolving has a target, it checks whether the target is still legal.
+// [...] If the target is illegal, the spell doesn't resolve. It's removed from the stack and put
+// into its owner's graveyard. 303.4f Otherwise it enters attached to the object it targeted.
+func (e *aura) resolve(g *game, a cardAction) {
+	targets := g.legalTargets(a.controller, a.targets)
+	if len(targets) == 0 {
+		g.moveCard(cardInstance{card: e, owner: a.controller}, zoneStack, zoneGraveyard)
+		return
+	}
+	g.moveCard(cardInstance{card: e, owner: a.controller, attachedTo: targets[0].id}, zoneStack, zoneBattlefield)
+}
+
+// 301.1 Artifact
+type artifact struct {
+	card
+}
+
+func (e *artifact) prereq(g *game, pindex int) bool {
+	return sorcerySpeed(g, pindex)
+}
+
+func (e *artifact) resolve(g *game, a cardAction) {
+	g.moveCard(cardInstance{card: e, owner: a.controller}, zoneStack, zoneBattlefield)
+}
+
+// 301.5 Some artifacts have the subtype "Equipment." An Equipment can be attached to a creature.
+// It enters the battlefield unattached; see the attach effect for its equip ability.
+type equipment struct {
+	card
+}
+
+func (e *equipment) prereq(g *game, pindex int) bool {
+	return sorcerySpeed(g, pindex)
+}
+
+func (e *equipment) resolve(g *game, a cardAction) {
+	g.moveCard(cardInstance{card: e, owner: a.controller}, zoneStack, zoneBattlefield)
+}
+
+// 306.5b planeswalkers enter the battlefield with loyalty counters, see enterBattlefield
+type planeswalker struct {
+	card
+	loyalty int
+}
+
+func (pw *planeswalker) prereq(g *game, pindex int) bool {
+	return sorcerySpeed(g, pindex)
+}
+
+func (pw *planeswalker) resolve(g *game, a cardAction) {
+	g.moveCard(cardInstance{card: pw, owner: a.controller}, zoneStack, zoneBattlefield)
+}
+
+func isPlaneswalker(c Card) bool {
+	_, ok := c.(*planeswalker)
+	return ok
+}
+
+// 307.1 A player who has priority may cast a sorcery card from their hand during
+// a main phase of their turn when the stack is empty.
 func sorcerySpeed(g *game, pindex int) bool {
 	return g.isMainPhase() && len(g.stack) == 0 && g.activePlayer == pindex
 }
 
+// 304.1 A player who has priority may cast an instant card from their hand.
+func instantSpeed(g *game, pindex int) bool {
+	return g.priorityPlayer == pindex
+}
+
 // TODO: generate card data from online database
 // build once card structure has stabilised a bit more
 func getCard(name string) card {
@@ -184,6 +447,23 @@ func getCard(name string) card {
 	return card{name: cards[0].Name}
 }
 
+func (c orderedCards) copy() orderedCards {
+	if len(c) == 0 {
+		return c
+	}
+	newC := make(orderedCards, len(c))
+	copy(newC, c)
+	return newC
+}
+
+func (c unorderedCards) size() int {
+	n := 0
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
 func (c unorderedCards) String() string {
 	var ss []string
 	for k, v := range c {
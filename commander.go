@@ -0,0 +1,152 @@
+package main
+
+import "fmt"
+
+// 903.1 In the Commander variant, each deck is led by a legendary creature designated as that deck's commander.
+// A commander game is a normal game, see newGame, between players set up with newCommanderPlayer.
+
+// 105.1 There are five colors in the Magic game
+type colors struct {
+	w, u, b, r, g bool
+}
+
+func colorsOf(m mana) colors {
+	return colors{w: m.w > 0, u: m.u > 0, b: m.b > 0, r: m.r > 0, g: m.g > 0}
+}
+
+func (c colors) union(o colors) colors {
+	return colors{w: c.w || o.w, u: c.u || o.u, b: c.b || o.b, r: c.r || o.r, g: c.g || o.g}
+}
+
+func (c colors) within(o colors) bool {
+	return (!c.w || o.w) && (!c.u || o.u) && (!c.b || o.b) && (!c.r || o.r) && (!c.g || o.g)
+}
+
+// 903.4 The Commander variant uses color identity to determine what cards can be in a deck.
+// A card's color identity is its color plus the color of any mana symbols in the card's rules text.
+// assumption: mana symbols in rules text only appear in activated ability costs and in mana being added
+func colorIdentity(c Card) colors {
+	identity := colorsOf(c.getManaCost())
+	for _, aa := range c.getActivatedAbilities() {
+		identity = identity.union(colorsOf(aa.cost.mana))
+		if am, ok := aa.getEffect().(addMana); ok {
+			identity = identity.union(colorsOf(am.amount))
+		}
+	}
+	if s, ok := c.(spell); ok {
+		if am, ok := s.getSpellAbility().getEffect().(addMana); ok {
+			identity = identity.union(colorsOf(am.amount))
+		}
+	}
+	return identity
+}
+
+// validateCommanderDeck checks the deck construction rules. The deck includes its commander.
+// 903.3 Each deck has a legendary creature card designated as its commander.
+// 903.5a Each deck must contain exactly 100 cards, including its commander.
+// 903.5b Other than basic lands, each card in a Commander deck must have a different English name.
+// 903.5c A card can be included in a Commander deck only if every mana symbol in its mana cost
+// and rules text is within the color identity of the deck's commander.
+func validateCommanderDeck(commander Card, deck unorderedCards) error {
+	if _, ok := commander.(*creature); !ok || !commander.isLegendary() {
+		return fmt.Errorf("commander %s is not a legendary creature", commander.getName())
+	}
+	if deck[commander] != 1 {
+		return fmt.Errorf("deck should contain its commander %s once", commander.getName())
+	}
+	if n := deck.size(); n != 100 {
+		return fmt.Errorf("deck contains %d cards, should be 100", n)
+	}
+	names := map[string]int{}
+	for c, n := range deck {
+		names[c.getName()] += n
+	}
+	identity := colorIdentity(commander)
+	for c := range deck {
+		if names[c.getName()] > 1 && !isBasicLand(c) {
+			return fmt.Errorf("deck contains more than one %s", c.getName())
+		}
+		if !colorIdentity(c).within(identity) {
+			return fmt.Errorf("%s is outside the color identity of %s", c.getName(), commander.getName())
+		}
+	}
+	return nil
+}
+
+// newCommanderPlayer sets up a player for a commander game with a deck including its commander
+func newCommanderPlayer(idx int, name string, commander Card, deck unorderedCards) (*player, error) {
+	if err := validateCommanderDeck(commander, deck); err != nil {
+		return nil, err
+	}
+	library := unorderedCards{}
+	for c, n := range deck {
+		if c != commander {
+			library[c] = n
+		}
+	}
+	p := newPlayer(idx, name, library)
+	p.deckList = deck
+	// 903.6 At the start of the game, each player puts their commander from their deck face up into the command zone.
+	p.commander = commander
+	p.command = orderedCards{commander}
+	// 903.7 each player sets their life total to 40
+	p.lifeTotal = 40
+	return p, nil
+}
+
+func (g *game) isCommander(c cardInstance) bool {
+	return c.card != nil && g.getPlayer(c.owner).commander == c.card
+}
+
+// 903.9a If a commander is in a graveyard or in exile [...], its owner may put it into the command zone.
+// 903.9b If a commander would be put into its owner's hand or library from anywhere,
+// its owner may put it into the command zone instead.
+// assumption: owners always choose to do so
+func commanderZone(to zone) zone {
+	switch to {
+	case zoneGraveyard, zoneExile, zoneHand, zoneLibrary:
+		return zoneCommand
+	}
+	return to
+}
+
+// 903.8 A player may cast a commander they own from the command zone. A commander cast from
+// the command zone costs an additional {2} for each previous time the player casting it
+// has cast it from the command zone that game.
+func (p *player) commanderTax() mana {
+	return mana{c: 2 * p.commanderCasts}
+}
+
+func (p *player) canCastCommander(g *game) bool {
+	if p.commander == nil || len(p.command) == 0 || p.command[0] != p.commander {
+		return false
+	}
+	return p.canCast(g, p.commander, g.manaCost(p.idx, p.commander).add(p.commanderTax()))
+}
+
+func (p *player) castCommander() cardAction {
+	return cardAction{card: p.commander, action: action{controller: p.idx}, fromCommandZone: true}
+}
+
+// 903.10a Commander damage is tracked per commander over the course of the game
+func (g *game) trackCommanderDamage(source cardInstance, player, amount int) {
+	if amount <= 0 || !g.isCommander(source) {
+		return
+	}
+	p := g.getPlayer(player)
+	if p.commanderDamage == nil {
+		p.commanderDamage = map[int]int{}
+	}
+	p.commanderDamage[source.owner] += amount
+}
+
+// 704.6c In a Commander game, a player who's been dealt 21 or more combat damage
+// by the same commander over the course of the game loses the game.
+func (p *player) commanderDamageLethal() bool {
+	for _, n := range p.commanderDamage {
+		if n >= 21 {
+			return true
+		}
+	}
+	return false
+}
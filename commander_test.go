@@ -0,0 +1,140 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateCommanderDeck(t *testing.T) {
+	for i, tt := range []struct {
+		name      string
+		commander Card
+		deck      unorderedCards
+		wantErr   bool
+	}{
+		{
+			name:      "valid deck",
+			commander: baral,
+			deck:      commanderDeckList,
+		},
+		{
+			name:      "commander should be a legendary creature",
+			commander: falkenrathReaver,
+			deck:      unorderedCards{falkenrathReaver: 1, mountain: 99},
+			wantErr:   true,
+		},
+		{
+			name:      "commander should be in the deck",
+			commander: baral,
+			deck:      unorderedCards{island: 100},
+			wantErr:   true,
+		},
+		{
+			name:      "deck should be 100 cards",
+			commander: baral,
+			deck:      unorderedCards{baral: 1, island: 60},
+			wantErr:   true,
+		},
+		{
+			name:      "singleton except for basic lands",
+			commander: baral,
+			deck:      unorderedCards{baral: 1, counterspell: 2, island: 97},
+			wantErr:   true,
+		},
+		{
+			name:      "outside color identity",
+			commander: baral,
+			deck:      unorderedCards{baral: 1, lavaSpike: 1, island: 98},
+			wantErr:   true,
+		},
+		{
+			name:      "mana abilities count towards color identity",
+			commander: baral,
+			deck:      unorderedCards{baral: 1, volcanicIsland: 1, island: 98},
+			wantErr:   true,
+		},
+	} {
+		err := validateCommanderDeck(tt.commander, tt.deck)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%d: %s) got error %v, want error %t", i, tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewCommanderPlayer(t *testing.T) {
+	p, err := newCommanderPlayer(SELF, "player", baral, commanderDeckList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.lifeTotal != 40 {
+		t.Errorf("life total: got %d want 40", p.lifeTotal)
+	}
+	if !reflect.DeepEqual(p.command, orderedCards{baral}) {
+		t.Errorf("commander should start in the command zone: got %v", p.command)
+	}
+	if len(p.library) != 99 {
+		t.Errorf("library: got %d cards want 99", len(p.library))
+	}
+}
+
+func TestCommanderTax(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{
+				lifeTotal:   40,
+				strategy:    goldfish{},
+				commander:   baral,
+				command:     orderedCards{baral},
+				battlefield: battlefield{lands: []cardInstance{instanceOf(island), instanceOf(island), instanceOf(island), instanceOf(island)}},
+			},
+			OPP: &player{lifeTotal: 40, strategy: goldfish{}},
+		},
+		numPlayers:  2,
+		currentStep: precombatMainPhase,
+	}
+	p := g.getPlayer(SELF)
+	if !p.canCastCommander(g) {
+		t.Fatalf("should be able to cast commander")
+	}
+	g.resolveAction(p.castCommander())
+	if len(p.command) != 0 || len(g.stack) != 1 {
+		t.Fatalf("commander should be cast from the command zone")
+	}
+	g.resolve()
+	if len(p.battlefield.creatures) != 1 {
+		t.Fatalf("commander should be on the battlefield")
+	}
+	// 903.9a the commander returns to the command zone instead of dying
+	g.moveCard(p.battlefield.creatures[0], zoneBattlefield, zoneGraveyard)
+	if len(p.graveyard) != 0 || !reflect.DeepEqual(p.command, orderedCards{baral}) {
+		t.Fatalf("commander should return to the command zone: graveyard %v command %v", p.graveyard, p.command)
+	}
+	// two lands left, but casting it again costs {2} more
+	if p.canCastCommander(g) {
+		t.Errorf("should not be able to pay the commander tax")
+	}
+	if got := g.manaCost(SELF, baral).add(p.commanderTax()); got != (mana{c: 3, u: 1}) {
+		t.Errorf("commander cost: got %v want %v", got, mana{c: 3, u: 1})
+	}
+}
+
+func TestCommanderDamage(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{lifeTotal: 40, commander: baral},
+			OPP:  &player{lifeTotal: 40},
+		},
+		numPlayers: 2,
+	}
+	commander := cardInstance{id: 1, card: baral, owner: SELF}
+	creature := cardInstance{id: 2, card: falkenrathReaver, owner: SELF}
+	g.combatDamageToPlayer(creature, SELF, OPP, 15)
+	g.combatDamageToPlayer(commander, SELF, OPP, 15)
+	if g.getPlayer(OPP).losesGame() {
+		t.Fatalf("only 15 commander damage dealt")
+	}
+	g.combatDamageToPlayer(commander, SELF, OPP, 6)
+	if !g.getPlayer(OPP).losesGame() {
+		t.Errorf("21 combat damage from the same commander should lose the game")
+	}
+}
@@ -1,10 +1,8 @@
 package main
 
-// For permanent targets this might get hairy if their index changes (?)
-// but players never change so this is simpler
-// TODO: imagine targeting a creature by index, in response a creature with smaller
-// index is killed, if battlefield array is reordered this goes very wrong
-// TODO: use cardinstance.ID instead!
+// Players are targeted by index, permanents by cardInstance.id
+// and spells on the stack by their id, see effectTarget.
+// Effects are only applied to targets that are still legal on resolution.
 type Effect interface {
 	apply(g *game, targets []effectTarget)
 }
@@ -17,11 +15,30 @@ func (e draw) apply(g *game, targets []effectTarget) {
 	for _, t := range targets {
 		switch t.ttype {
 		case you, targetPlayer:
-			g.getPlayer(int(t.index)).drawN(e.amount)
+			g.drawCards(int(t.index), e.amount)
 		case eachPlayer:
-			for _, p := range g.players {
-				p.drawN(e.amount)
+			for _, i := range g.apnapOrder() {
+				g.drawCards(i, e.amount)
 			}
+		case eachOpponent:
+			for _, i := range g.getOpponents(int(t.index)) {
+				g.drawCards(i, e.amount)
+			}
+		}
+	}
+}
+
+// 701.13a For a player to mill a number of cards, that player puts that many cards
+// from the top of their library into their graveyard.
+type mill struct {
+	amount int
+}
+
+func (e mill) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		p := g.getPlayer(int(t.index))
+		for j := 0; j < e.amount && len(p.library) > 0; j++ {
+			g.moveCard(cardInstance{card: p.library[0], owner: int(t.index)}, zoneLibrary, zoneGraveyard)
 		}
 	}
 }
@@ -32,12 +49,22 @@ type damage struct {
 
 func (e damage) apply(g *game, targets []effectTarget) {
 	for _, t := range targets {
+		if t.isPermanent() {
+			// 120.3e Damage dealt to a creature causes that much damage to be marked on it.
+			c, controller := g.findPermanent(t.id)
+			g.dealDamage(event{etype: damageDealt, player: controller, id: c.id, card: c.card, amount: e.amount})
+			continue
+		}
 		switch t.ttype {
-		case you, targetPlayer:
-			g.getPlayer(int(t.index)).lifeTotal -= e.amount
+		case you, targetPlayer, anyTarget, targetPlayerOrPlaneswalker:
+			g.damagePlayer(int(t.index), e.amount)
 		case eachPlayer:
-			for _, p := range g.players {
-				p.lifeTotal -= e.amount
+			for _, i := range g.apnapOrder() {
+				g.damagePlayer(i, e.amount)
+			}
+		case eachOpponent:
+			for _, i := range g.getOpponents(int(t.index)) {
+				g.damagePlayer(i, e.amount)
 			}
 		}
 	}
@@ -52,7 +79,18 @@ func (e lifegain) apply(g *game, targets []effectTarget) {
 		if !t.ttype.isPlayer() {
 			panic("wrong target type")
 		}
-		g.getPlayer(int(t.index)).lifeTotal += e.amount
+		switch t.ttype {
+		case eachPlayer:
+			for _, i := range g.apnapOrder() {
+				g.gainLife(i, e.amount)
+			}
+		case eachOpponent:
+			for _, i := range g.getOpponents(int(t.index)) {
+				g.gainLife(i, e.amount)
+			}
+		default:
+			g.gainLife(int(t.index), e.amount)
+		}
 	}
 }
 
@@ -67,3 +105,203 @@ func (e addMana) apply(g *game, targets []effectTarget) {
 	p := g.getPlayer(int(targets[0].index))
 	p.manaPool = p.manaPool.add(e.amount)
 }
+
+// 701.5a To counter a spell or ability means to cancel it, removing it from the stack.
+// 701.5b A countered spell is put into its owner's graveyard.
+type counter struct{}
+
+func (e counter) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if t.ttype != targetSpell {
+			panic("wrong target type")
+		}
+		i := g.findOnStack(t.id)
+		if i == -1 {
+			continue
+		}
+		a := g.stack[i].(cardAction)
+		g.stack = append(g.stack[:i:i], g.stack[i+1:]...)
+		g.moveCard(cardInstance{card: a.card, owner: a.controller}, zoneStack, zoneGraveyard)
+	}
+}
+
+// 701.7a To destroy a permanent, move it from the battlefield to its owner's graveyard.
+type destroy struct{}
+
+func (e destroy) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if !t.isPermanent() {
+			panic("wrong target type")
+		}
+		g.moveCard(cardInstance{id: t.id}, zoneBattlefield, zoneGraveyard)
+	}
+}
+
+// return target permanent to its owner's hand
+type bounce struct{}
+
+func (e bounce) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if !t.isPermanent() {
+			panic("wrong target type")
+		}
+		g.moveCard(cardInstance{id: t.id}, zoneBattlefield, zoneHand)
+	}
+}
+
+// 406.2 To exile an object is to put it into the exile zone from whatever zone it's currently in.
+type exile struct{}
+
+func (e exile) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if !t.isPermanent() {
+			panic("wrong target type")
+		}
+		g.moveCard(cardInstance{id: t.id}, zoneBattlefield, zoneExile)
+	}
+}
+
+// 'target creature gets +N/+N until end of turn'
+type pump struct {
+	power, toughness int
+}
+
+func (e pump) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if !t.isPermanent() {
+			panic("wrong target type")
+		}
+		g.addEffect(continuousEffect{layer: modifyPTLayer, power: e.power, toughness: e.toughness}, -1, []uint64{t.id}, untilEndOfTurn)
+	}
+}
+
+// apply several effects to the same targets in order,
+// i.e. 'deal 2 damage to target creature. If that creature would die this turn, exile it instead.'
+type sequence []Effect
+
+func (e sequence) apply(g *game, targets []effectTarget) {
+	for _, eff := range e {
+		eff.apply(g, targets)
+	}
+}
+
+// a replacement or prevention effect lasting until end of turn,
+// applying to the target permanents or to events involving the controller
+type addReplacement struct {
+	replacement replacement
+}
+
+func (e addReplacement) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if t.isPermanent() {
+			g.addReplacement(e.replacement, -1, []uint64{t.id}, untilEndOfTurn)
+			continue
+		}
+		g.addReplacement(e.replacement, int(t.index), nil, untilEndOfTurn)
+	}
+}
+
+// 104.3h an effect can state that a player wins the game:
+// all of their opponents lose instead, since we only track losing
+type winGame struct{}
+
+func (e winGame) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		for _, i := range g.getOpponents(int(t.index)) {
+			g.getPlayer(i).lost = true
+		}
+	}
+}
+
+// effects that refer to the permanent they originate from, i.e. 'attach this equipment'
+type sourcedEffect interface {
+	Effect
+	withSource(id uint64) Effect
+}
+
+// 701.3a To attach an Aura, Equipment, or Fortification to an object means to take it from
+// where it currently is and put it onto that object.
+type attach struct {
+	source uint64
+}
+
+func (e attach) withSource(id uint64) Effect {
+	e.source = id
+	return e
+}
+
+func (e attach) apply(g *game, targets []effectTarget) {
+	c, _ := g.findPermanent(e.source)
+	if c == nil {
+		return
+	}
+	for _, t := range targets {
+		// 701.3b If an effect tries to attach an Aura, Equipment, or Fortification to an object
+		// it can't be attached to, or to the object it's already attached to, the effect does nothing.
+		if !t.isPermanent() || c.attachedTo == t.id {
+			continue
+		}
+		c.attachedTo = t.id
+		// 613.7e An Aura, Equipment, or Fortification receives a new timestamp at the time it becomes attached
+		c.timestamp = g.nextTimestamp()
+	}
+}
+
+// 111.2 The player who creates a token is its owner. The token enters the battlefield
+// under that player's control.
+type createToken struct {
+	token  Card
+	amount int
+}
+
+func (e createToken) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if t.ttype != you {
+			panic("wrong target type")
+		}
+		for j := 0; j < e.amount; j++ {
+			g.enterBattlefield(cardInstance{card: e.token, owner: int(t.index)}, int(t.index), zoneNone)
+		}
+	}
+}
+
+// 707.2 'create a token that's a copy of target creature', under the control of that creature's controller
+type createTokenCopy struct{}
+
+func (e createTokenCopy) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if !t.isPermanent() {
+			panic("wrong target type")
+		}
+		c, controller := g.findPermanent(t.id)
+		g.enterBattlefield(cardInstance{card: tokenCopy(c.card), owner: controller}, controller, zoneNone)
+	}
+}
+
+// 122.6 put counters on target permanents or players, or remove them with a negative amount
+type putCounters struct {
+	counter counterType
+	amount  int
+}
+
+func (e putCounters) apply(g *game, targets []effectTarget) {
+	for _, t := range targets {
+		if t.isPermanent() {
+			c, _ := g.findPermanent(t.id)
+			c.addCounters(e.counter, e.amount)
+			continue
+		}
+		switch t.ttype {
+		case eachPlayer:
+			for _, i := range g.apnapOrder() {
+				g.getPlayer(i).addCounters(e.counter, e.amount)
+			}
+		case eachOpponent:
+			for _, i := range g.getOpponents(int(t.index)) {
+				g.getPlayer(i).addCounters(e.counter, e.amount)
+			}
+		default:
+			g.getPlayer(int(t.index)).addCounters(e.counter, e.amount)
+		}
+	}
+}
@@ -104,3 +104,163 @@ func TestApplyEachPlayerEffect(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyCounterEffect(t *testing.T) {
+	for i, tt := range []struct {
+		// targets: ids of the spells to counter, one after the other
+		targets       []uint64
+		game          *game
+		wantStack     []stackObject
+		wantGraveyard orderedCards
+	}{
+		{
+			targets: []uint64{1},
+			game: &game{
+				numPlayers: 2,
+				players: []*player{
+					&player{},
+					&player{},
+				},
+				stack: []stackObject{
+					cardAction{id: 1, card: lavaSpike, action: action{controller: OPP}},
+					cardAction{id: 2, card: shock, action: action{controller: SELF}},
+				},
+			},
+			wantStack:     []stackObject{cardAction{id: 2, card: shock, action: action{controller: SELF}}},
+			wantGraveyard: orderedCards{lavaSpike},
+		},
+		{
+			// countering a spell lower on the stack doesn't change what the next counter targets
+			targets: []uint64{1, 2},
+			game: &game{
+				numPlayers: 2,
+				players: []*player{
+					&player{},
+					&player{},
+				},
+				stack: []stackObject{
+					cardAction{id: 1, card: lavaSpike, action: action{controller: OPP}},
+					cardAction{id: 2, card: divination, action: action{controller: OPP}},
+					cardAction{id: 3, card: flameRift, action: action{controller: OPP}},
+				},
+			},
+			wantStack:     []stackObject{cardAction{id: 3, card: flameRift, action: action{controller: OPP}}},
+			wantGraveyard: orderedCards{lavaSpike, divination},
+		},
+	} {
+		for _, id := range tt.targets {
+			counter{}.apply(tt.game, []effectTarget{{id: id, ttype: targetSpell}})
+		}
+		if !reflect.DeepEqual(tt.game.stack, tt.wantStack) {
+			t.Errorf("%d) stack got %v want %v", i, tt.game.stack, tt.wantStack)
+		}
+		got := tt.game.getPlayer(OPP).graveyard
+		if !reflect.DeepEqual(got, tt.wantGraveyard) {
+			t.Errorf("%d) graveyard got %v want %v", i, got, tt.wantGraveyard)
+		}
+	}
+}
+
+func TestApplyPermanentEffect(t *testing.T) {
+	for i, tt := range []struct {
+		name          string
+		effect        Effect
+		want          []cardInstance
+		wantHand      unorderedCards
+		wantGraveyard orderedCards
+		wantExile     orderedCards
+	}{
+		{
+			name:   "damage",
+			effect: damage{1},
+			want:   []cardInstance{{id: 1, card: falkenrathReaver, owner: OPP, damage: 1}, {id: 2, card: falkenrathReaver, owner: OPP}},
+		},
+		{
+			name:   "counters",
+			effect: putCounters{counter: plusOneCounter, amount: 1},
+			want:   []cardInstance{{id: 1, card: falkenrathReaver, owner: OPP, counters: map[counterType]int{plusOneCounter: 1}}, {id: 2, card: falkenrathReaver, owner: OPP}},
+		},
+		{
+			name:          "destroy",
+			effect:        destroy{},
+			want:          []cardInstance{{id: 2, card: falkenrathReaver, owner: OPP}},
+			wantGraveyard: orderedCards{falkenrathReaver},
+		},
+		{
+			name:     "bounce",
+			effect:   bounce{},
+			want:     []cardInstance{{id: 2, card: falkenrathReaver, owner: OPP}},
+			wantHand: unorderedCards{falkenrathReaver: 1},
+		},
+		{
+			name:      "exile",
+			effect:    exile{},
+			want:      []cardInstance{{id: 2, card: falkenrathReaver, owner: OPP}},
+			wantExile: orderedCards{falkenrathReaver},
+		},
+	} {
+		g := &game{
+			numPlayers: 2,
+			players: []*player{
+				SELF: &player{},
+				OPP: &player{battlefield: battlefield{creatures: []cardInstance{
+					{id: 1, card: falkenrathReaver, owner: OPP}, {id: 2, card: falkenrathReaver, owner: OPP},
+				}}},
+			},
+		}
+		tt.effect.apply(g, []effectTarget{{id: 1, ttype: targetCreature}})
+		opp := g.getPlayer(OPP)
+		if !reflect.DeepEqual(opp.battlefield.creatures, tt.want) {
+			t.Errorf("%d: %s) battlefield got %v want %v", i, tt.name, opp.battlefield.creatures, tt.want)
+		}
+		if !reflect.DeepEqual(opp.hand, tt.wantHand) {
+			t.Errorf("%d: %s) hand got %v want %v", i, tt.name, opp.hand, tt.wantHand)
+		}
+		if !reflect.DeepEqual(opp.graveyard, tt.wantGraveyard) {
+			t.Errorf("%d: %s) graveyard got %v want %v", i, tt.name, opp.graveyard, tt.wantGraveyard)
+		}
+		if !reflect.DeepEqual(opp.exile, tt.wantExile) {
+			t.Errorf("%d: %s) exile got %v want %v", i, tt.name, opp.exile, tt.wantExile)
+		}
+	}
+}
+
+func TestCreateToken(t *testing.T) {
+	g := &game{
+		numPlayers:     2,
+		players:        []*player{SELF: &player{lifeTotal: 20, strategy: goldfish{}}, OPP: &player{lifeTotal: 20, strategy: goldfish{}}},
+		priorityPlayer: SELF,
+	}
+	self := g.getPlayer(SELF)
+	dragonFodder.getSpellAbility().getEffect().apply(g, []effectTarget{{index: target(SELF), ttype: you}})
+	if got := len(self.battlefield.creatures); got != 2 {
+		t.Fatalf("creatures: got %d want %d", got, 2)
+	}
+	for _, c := range self.battlefield.creatures {
+		if !c.card.isToken() || !c.summoningSickness || c.attacking != -1 {
+			t.Errorf("token should enter the battlefield like a creature: got %+v", c)
+		}
+	}
+	bounce{}.apply(g, []effectTarget{{id: self.battlefield.creatures[0].id, ttype: targetCreature}})
+	destroy{}.apply(g, []effectTarget{{id: self.battlefield.creatures[0].id, ttype: targetCreature}})
+	if len(self.hand) != 1 || len(self.graveyard) != 1 {
+		t.Fatalf("tokens should briefly exist in other zones: hand %v graveyard %v", self.hand, self.graveyard)
+	}
+	g.checkStateBasedActions()
+	if len(self.hand) != 0 || len(self.graveyard) != 0 {
+		t.Errorf("tokens should cease to exist outside the battlefield: hand %v graveyard %v", self.hand, self.graveyard)
+	}
+
+	self.battlefield.creatures = []cardInstance{{id: 1, card: falkenrathReaver, damage: 1}}
+	createTokenCopy{}.apply(g, []effectTarget{{id: 1, ttype: targetCreatureYouControl}})
+	if got := len(self.battlefield.creatures); got != 2 {
+		t.Fatalf("creatures: got %d want %d", got, 2)
+	}
+	cp := self.battlefield.creatures[1]
+	if cp.card.getName() != falkenrathReaver.getName() || !cp.card.isToken() || cp.damage != 0 {
+		t.Errorf("copy should be a token with the copiable values of the original: got %+v", cp)
+	}
+	if falkenrathReaver.isToken() {
+		t.Errorf("copying should not change the original card")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import "math/rand"
+
+// 603.2 Whenever a game event or game state matches a triggered ability's trigger event,
+// that ability automatically triggers. The ability doesn't do anything at this point.
+// 603.3 Once an ability has triggered, its controller puts it on the stack as an object
+// the next time a player would receive priority.
+
+type eventType int
+
+const (
+	// 603.6 zone-change triggers: entersTheBattlefield, dies and leavesTheBattlefield
+	// are all zoneChange events, see event.is
+	zoneChange eventType = iota
+	entersTheBattlefield
+	dies
+	leavesTheBattlefield
+	attacks
+	beginningOfUpkeep
+	spellCast
+	damageDealt
+	cardDrawn
+	lifeGained
+)
+
+type event struct {
+	etype eventType
+	// player: index in game.players of the player the event happened to,
+	// i.e. the controller of the permanent, the caster, the drawing or damaged player
+	player int
+	// id: cardInstance.id of the permanent the event happened to, if any
+	id uint64
+	// card: the card the event happened to, if any
+	card   Card
+	amount int
+	// combat: the damage is combat damage
+	combat bool
+	// infect: the damage is dealt by a source with infect
+	infect bool
+	// from and to: the zones of a zoneChange event
+	from, to zone
+}
+
+func (e event) is(t eventType) bool {
+	switch t {
+	case entersTheBattlefield:
+		return e.etype == zoneChange && e.to == zoneBattlefield
+	case leavesTheBattlefield:
+		return e.etype == zoneChange && e.from == zoneBattlefield
+	case dies:
+		// 700.4 The term dies means "is put into a graveyard from the battlefield."
+		// we only use it for creatures
+		_, isCreature := e.card.(*creature)
+		return isCreature && e.etype == zoneChange && e.from == zoneBattlefield && e.to == zoneGraveyard
+	}
+	return e.etype == t
+}
+
+// trigger conditions: the event type and whose event it has to be
+type trigger struct {
+	event eventType
+	// self: only the source permanent itself, i.e. 'when this creature dies'
+	self bool
+	// you: only events happening to the controller of the source, i.e. 'whenever you cast'
+	you bool
+	// filter on the card involved, i.e. 'whenever you cast an instant or sorcery spell'
+	filter func(Card) bool
+}
+
+func (t trigger) matches(e event, source cardInstance, controller int) bool {
+	if !e.is(t.event) {
+		return false
+	}
+	if t.self && e.id != source.id {
+		return false
+	}
+	if t.you && e.player != controller {
+		return false
+	}
+	if t.filter != nil && !t.filter(e.card) {
+		return false
+	}
+	return true
+}
+
+type pendingTrigger struct {
+	controller int
+	source     Card
+	sourceID   uint64
+	ability    TriggeredAbility
+}
+
+// emit checks all permanents on the battlefield for abilities that trigger on e
+func (g *game) emit(e event) {
+	for i, p := range g.players {
+		for _, c := range p.permanents() {
+			g.checkTriggers(e, c, i)
+		}
+	}
+	// 603.10a leaves-the-battlefield abilities look back in time:
+	// the permanent that died can trigger on its own death
+	if e.is(leavesTheBattlefield) {
+		g.checkTriggers(e, cardInstance{id: e.id, card: e.card}, e.player)
+	}
+}
+
+func (g *game) checkTriggers(e event, source cardInstance, controller int) {
+	for _, ta := range source.card.getTriggeredAbilities() {
+		if !ta.trigger.matches(e, source, controller) {
+			continue
+		}
+		g.pendingTriggers = append(g.pendingTriggers, pendingTrigger{
+			controller: controller,
+			source:     source.card,
+			sourceID:   source.id,
+			ability:    ta,
+		})
+	}
+}
+
+// 603.3b If multiple players have triggered abilities that have triggered since the last time
+// a player received priority, each player, in APNAP order, puts triggered abilities they control
+// on the stack in any order they choose.
+// TODO: let players choose the order of their own triggers
+func (g *game) putTriggersOnStack() bool {
+	if len(g.pendingTriggers) == 0 {
+		return false
+	}
+	pending := g.pendingTriggers
+	g.pendingTriggers = nil
+	for _, i := range g.apnapOrder() {
+		p := g.getPlayer(i)
+		for _, t := range pending {
+			if t.controller != i {
+				continue
+			}
+			// 603.3c/d targets are chosen when the ability is put on the stack;
+			// if no legal targets can be chosen, the ability is removed
+			options := getTargets(g, t.ability, i)
+			if len(options) == 0 {
+				continue
+			}
+			targets := options[0]
+			if len(options) > 1 {
+				targets = p.strategy.ChooseTargets(p, g, options)
+			}
+			g.stack = append(g.stack, abilityOnStack{
+				action:   action{controller: i},
+				id:       rand.Uint64(),
+				source:   t.source,
+				sourceID: t.sourceID,
+				ability:  t.ability,
+				targets:  targets,
+			})
+		}
+	}
+	g.numPasses = 0
+	return true
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTriggeredAbilities(t *testing.T) {
+	for i, tt := range []struct {
+		name      string
+		self      *player
+		opp       *player
+		stack     []stackObject
+		action    Action
+		wantStack []string
+		// wantControllers: controllers of the objects on the stack from the bottom up, if checked
+		wantControllers []int
+	}{
+		{
+			name: "enters the battlefield",
+			self: &player{
+				battlefield: battlefield{lands: []cardInstance{{card: mountain}}},
+			},
+			opp: &player{
+				battlefield: battlefield{creatures: []cardInstance{{id: 2, card: falkenrathReaver}}},
+			},
+			stack:     []stackObject{cardAction{card: flametongueKavu, action: action{controller: SELF}}},
+			action:    passAction{action{controller: OPP}},
+			wantStack: []string{"Flametongue Kavu ability"},
+		},
+		{
+			name: "whenever you cast",
+			self: &player{
+				hand:        unorderedCards{lavaSpike: 1},
+				battlefield: battlefield{lands: []cardInstance{{card: mountain}}, creatures: []cardInstance{{id: 1, card: guttersnipe}}},
+			},
+			opp: &player{},
+			action: cardAction{
+				card:    lavaSpike,
+				action:  action{controller: SELF},
+				targets: []effectTarget{{index: target(OPP), ttype: targetPlayer}},
+			},
+			wantStack: []string{"Lava Spike", "Guttersnipe ability"},
+		},
+		{
+			name: "dies",
+			self: &player{
+				battlefield: battlefield{creatures: []cardInstance{{id: 1, card: goblinArsonist, damage: 1}}},
+			},
+			opp:       &player{},
+			wantStack: []string{"Goblin Arsonist ability"},
+		},
+		{
+			name: "APNAP order",
+			self: &player{
+				battlefield: battlefield{creatures: []cardInstance{{id: 1, card: goblinArsonist, damage: 1}}},
+			},
+			opp: &player{
+				battlefield: battlefield{creatures: []cardInstance{{id: 2, card: goblinArsonist, damage: 1}}},
+			},
+			wantStack: []string{"Goblin Arsonist ability", "Goblin Arsonist ability"},
+			// the active player's trigger is put on the stack first
+			wantControllers: []int{SELF, OPP},
+		},
+	} {
+		tt.self.idx, tt.self.lifeTotal, tt.self.strategy = SELF, 20, simpleStrategy{}
+		tt.opp.idx, tt.opp.lifeTotal, tt.opp.strategy = OPP, 20, simpleStrategy{}
+		g := &game{
+			players:        []*player{SELF: tt.self, OPP: tt.opp},
+			numPlayers:     2,
+			activePlayer:   SELF,
+			priorityPlayer: SELF,
+			currentStep:    precombatMainPhase,
+			stack:          tt.stack,
+		}
+		if tt.action != nil {
+			g.priorityPlayer = tt.action.getController()
+			g.numPasses = len(tt.stack)
+			g.resolveAction(tt.action)
+		}
+		g.checkStateBasedActions()
+		got := []string{}
+		for _, o := range g.stack {
+			got = append(got, o.getName())
+		}
+		if !reflect.DeepEqual(got, tt.wantStack) {
+			t.Errorf("%d: %s) got %v want %v", i, tt.name, got, tt.wantStack)
+		}
+		if tt.wantControllers == nil {
+			continue
+		}
+		controllers := []int{}
+		for _, o := range g.stack {
+			controllers = append(controllers, o.getController())
+		}
+		if !reflect.DeepEqual(controllers, tt.wantControllers) {
+			t.Errorf("%d: %s) controllers: got %v want %v", i, tt.name, controllers, tt.wantControllers)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math/rand"
 )
 
 type step int
@@ -53,7 +54,7 @@ func stepName(step step) string {
 
 type game struct {
 	players        []*player
-	stack          []cardAction
+	stack          []stackObject
 	currentStep    step
 	turn           int
 	activePlayer   int
@@ -67,41 +68,107 @@ type game struct {
 	// decided NOT to split in subphases for clarity later on.
 	declarations int
 	numAttackers int
+	// triggered abilities waiting to be put on the stack
+	pendingTriggers []pendingTrigger
+	// continuous effects from resolved spells and abilities
+	continuousEffects []activeEffect
+	// replacement and prevention effects from resolved spells and abilities
+	replacementEffects []activeReplacement
+	timestamp          int
 }
 
 func newGame(startingPlayer int, players ...*player) *game {
 	fmt.Printf("Starting player: %s\n", players[startingPlayer].name)
-	for _, p := range players {
-		p.drawN(7)
-	}
 	g := &game{
 		players:        players,
-		currentStep:    precombatMainPhase,
+		currentStep:    untapStep,
 		turn:           1,
 		activePlayer:   startingPlayer,
 		priorityPlayer: startingPlayer,
 		startingPlayer: startingPlayer,
 		numPlayers:     len(players),
 	}
+	// 103.5 [...] starting with the starting player and proceeding in turn order,
+	// each player may take a mulligan.
+	for i := 0; i < g.numPlayers; i++ {
+		p := g.getPlayer((startingPlayer + i) % g.numPlayers)
+		g.drawCards(p.idx, 7)
+		if n := g.mulligan(p); n > 0 {
+			fmt.Printf("%s mulligans to %d\n", p.name, 7-n)
+		}
+	}
 	g.nextDecisionPoint()
 	return g
 }
 
-// getPlayerAction -> resolveAction -> check gameEnds -> repeat
+// 103.5 London mulligan: a player who takes a mulligan shuffles their hand into their library,
+// then draws a new hand of seven cards. Once a player keeps an opening hand,
+// they put a card from it on the bottom of their library for each mulligan taken.
+// Returns the number of mulligans taken.
+func (g *game) mulligan(p *player) int {
+	n := 0
+	// 103.5 [...] A player can take mulligans until their opening hand would be zero cards
+	for n < 7 && p.strategy.Mulligan(p.hand, n) {
+		g.shuffleHandIntoLibrary(p.idx)
+		g.drawCards(p.idx, 7)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	for _, c := range p.strategy.ChooseBottom(p, g, n) {
+		g.moveCardTo(cardInstance{card: c, owner: p.idx}, zoneHand, zoneLibrary, bottom)
+	}
+	return n
+}
+
+// check state-based actions -> getPlayerAction -> resolveAction -> repeat
 // rest is debugging print statements
 func (g *game) loop() {
 	for {
+		// 117.5 state-based actions are checked before a player receives priority
+		for _, i := range g.checkStateBasedActions() {
+			g.eliminate(i)
+			fmt.Printf("%s loses the game\n", g.getPlayer(i).name)
+		}
+		if g.isOver() {
+			g.debug()
+			fmt.Println("End of game")
+			return
+		}
 		a := g.getPlayerAction()
 		if _, ok := a.(passAction); !ok {
-			if at, ok := a.(attackAction); !ok || len(at.attackers) > 0 {
+			switch at := a.(type) {
+			case attackAction:
+				if len(at.attackers) > 0 {
+					g.debug()
+				}
+			case blockAction:
+				if len(at.blockers) > 0 {
+					g.debug()
+				}
+			default:
 				g.debug()
 			}
 		}
-		var ac cardAction
+		var ac stackObject
 		var stacklength int
+		var targetNames []string
 		if len(g.stack) != 0 {
 			stacklength = len(g.stack)
 			ac = g.stack[stacklength-1]
+			for _, target := range ac.getTargets() {
+				targetNames = append(targetNames, g.targetName(target))
+			}
+		}
+		if at, ok := a.(activateAction); ok {
+			// the source might be sacrificed as part of the cost
+			c, _ := g.findPermanent(at.id)
+			fmt.Printf("-> %s activates %s", g.getPlayer(at.controller).name, c.card.getName())
+			if len(at.targets) > 0 && !at.targets[0].ttype.isUntargeted() {
+				fmt.Printf(" targeting %s", g.targetName(at.targets[0]))
+			}
+			fmt.Println()
 		}
 		g.resolveAction(a)
 		switch at := a.(type) {
@@ -109,18 +176,24 @@ func (g *game) loop() {
 			fmt.Printf("-> %s passes\n", g.getPlayer(a.getController()).name)
 			if len(g.stack) < stacklength {
 				// ac resolved
-				for _, target := range ac.targets {
+				controller := g.getPlayer(ac.getController()).name
+				if len(ac.getTargets()) == 0 {
+					fmt.Printf("%s resolves by %s \n", ac.getName(), controller)
+				}
+				for i, target := range ac.getTargets() {
 					if target.ttype.isUntargeted() {
-						fmt.Printf("%s resolves by %s \n", ac.card.getName(), g.getPlayer(ac.controller).name)
+						fmt.Printf("%s resolves by %s \n", ac.getName(), controller)
 					} else {
-						fmt.Printf("%s resolves by %s targeting %s \n", ac.card.getName(), g.getPlayer(ac.controller).name, g.getPlayer(int(target.index)).name)
+						fmt.Printf("%s resolves by %s targeting %s \n", ac.getName(), controller, targetNames[i])
 					}
 				}
 			}
+		case playLandAction:
+			fmt.Printf("-> %s plays %s\n", g.getPlayer(at.controller).name, at.card.getName())
 		case cardAction:
 			fmt.Printf("-> %s plays %s", g.getPlayer(at.controller).name, at.card.getName())
-			if len(at.targets) > 0 {
-				fmt.Printf(" targeting %s", g.getPlayer(int(at.targets[0].index)).name)
+			if len(at.targets) > 0 && !at.targets[0].ttype.isUntargeted() {
+				fmt.Printf(" targeting %s", g.targetName(at.targets[0]))
 			}
 			fmt.Println()
 		case attackAction:
@@ -132,11 +205,17 @@ func (g *game) loop() {
 				attackers = append(attackers, c.card.getName())
 			}
 			fmt.Printf("-> %s attacks with %s \n", g.getPlayer(at.controller).name, attackers)
-		}
-		if gameEnds := g.checkStateBasedActions(); gameEnds {
-			g.debug()
-			fmt.Println("End of game")
-			return
+		case blockAction:
+			if len(at.blockers) == 0 {
+				break
+			}
+			blockers := []string{}
+			for _, b := range at.blockers {
+				blocker := g.getPlayer(at.controller).permanent(b.id)
+				attacker := g.getActivePlayer().permanent(b.blocks)
+				blockers = append(blockers, fmt.Sprintf("%s blocks %s", blocker.card.getName(), attacker.card.getName()))
+			}
+			fmt.Printf("-> %s declares blockers: %s \n", g.getPlayer(at.controller).name, blockers)
 		}
 	}
 }
@@ -152,33 +231,44 @@ func (g *game) resolveAction(action Action) {
 		// (that is, if all players pass without taking any actions in between passing),
 		// the spell or ability on top of the stack resolves or, if the stack is empty,
 		// the phase or step ends.
-		if g.numPasses == g.numPlayers {
+		if g.numPasses == len(g.apnapOrder()) {
 			g.numPasses = 0
 			if len(g.stack) != 0 {
 				g.resolve()
 			} else {
-				g.nextStep()
+				// 514.3a [...] Once the stack is empty and all players pass in succession,
+				// another cleanup step begins.
+				if g.currentStep != cleanupStep {
+					g.nextStep()
+				}
 				g.nextDecisionPoint()
 			}
 			// 116.3a The active player receives priority at the beginning of most steps and phases [...]
 			// 116.3b The active player receives priority after a spell or ability (other than a mana ability) resolves.
-			g.priorityPlayer = g.activePlayer
+			// If the active player has left the game, the next player in turn order does.
+			g.priorityPlayer = g.apnapOrder()[0]
 		}
 	case cardAction:
 		// 116.3c If a player has priority when they cast a spell,
 		// activate an ability, or take a special action, that player receives priority afterward.
 		g.numPasses = 0
 		g.play(a)
-		// TODO (currently a hack): special actions (such as playing land)
-		// do not always pass priority to the other player
-		if a.card == mountain {
-			g.resolve()
+	case playLandAction:
+		// 116.3c [...] or take a special action, that player receives priority afterward.
+		g.numPasses = 0
+		g.playLand(a)
+	case activateAction:
+		g.numPasses = 0
+		if g.isManaActivation(a) && !g.isLegalManaActivation(a) {
+			panic("illegal mana ability activation")
 		}
+		g.activate(a)
 	case attackAction:
 		g.declarations += 1
 		g.declareAttackers(a)
 	case blockAction:
 		g.declarations += 1
+		g.declareBlockers(a)
 	}
 }
 
@@ -189,9 +279,172 @@ func (g *game) getPlayer(i int) *player {
 	return g.players[i]
 }
 
-// Two player game for now, getOpponents() later
-func (g *game) getOpponent(i int) *player {
-	return g.getPlayer((i + 1) % 2)
+// turnOrder lists the players still in the game in turn order, starting with player i
+// or the first player after them if they have left the game
+func (g *game) turnOrder(i int) []int {
+	order := []int{}
+	for n := 0; n < g.numPlayers; n++ {
+		j := (i + n) % g.numPlayers
+		if g.getPlayer(j).lost {
+			continue
+		}
+		order = append(order, j)
+	}
+	return order
+}
+
+// 101.4 APNAP order: the active player first, then the other players in turn order
+func (g *game) apnapOrder() []int {
+	return g.turnOrder(g.activePlayer)
+}
+
+// nextPlayer is the first player after player i in turn order that is still in the game
+func (g *game) nextPlayer(i int) int {
+	return g.turnOrder(i + 1)[0]
+}
+
+// 102.2 / 102.3 in a free-for-all game, all other players still in the game are opponents,
+// listed in turn order after player i
+func (g *game) getOpponents(i int) []int {
+	opps := []int{}
+	for _, j := range g.turnOrder(i + 1) {
+		if j == i {
+			continue
+		}
+		opps = append(opps, j)
+	}
+	return opps
+}
+
+func (g *game) damagePlayer(i, amount int) {
+	g.dealDamage(event{etype: damageDealt, player: i, amount: amount})
+}
+
+// 506.2 In a multiplayer game, the active player may attack any of their opponents.
+// Each opponent declares blockers in turn order, whether they are attacked or not.
+func (g *game) defendingPlayers() []int {
+	return g.getOpponents(g.activePlayer)
+}
+
+// 508.1 the active player declares attackers once
+func (g *game) declaringAttackers() bool {
+	return g.currentStep == declareAttackersStep && g.declarations == 0 && !g.getActivePlayer().lost
+}
+
+// 509.1 blockers are declared once by each defending player
+func (g *game) declaringBlockers() bool {
+	return g.currentStep == declareBlockersStep && g.declarations < len(g.defendingPlayers()) && !g.getActivePlayer().lost
+}
+
+// 800.4a When a player leaves the game, all objects owned by that player leave the game and any effects
+// which give that player control of any objects or players end. [...] If that player controlled any
+// objects on the stack not represented by cards, those objects cease to exist. [...]
+// If there are any objects still controlled by that player, those objects are exiled.
+func (g *game) eliminate(i int) {
+	p := g.getPlayer(i)
+	p.lost = true
+	for j, q := range g.players {
+		for _, c := range q.permanents() {
+			switch {
+			case c.owner == i:
+				q.removePermanent(c.id)
+			case j == i:
+				g.moveCard(c, zoneBattlefield, zoneExile)
+			}
+		}
+		// creatures attacking a player who left the game are removed from combat
+		for k, c := range q.battlefield.creatures {
+			if c.attacking == i {
+				c.attacking = -1
+				c.attackingPlaneswalker = 0
+				q.battlefield.creatures[k] = c
+			}
+		}
+	}
+	var stack []stackObject
+	for _, o := range g.stack {
+		if o.getController() != i {
+			stack = append(stack, o)
+		}
+	}
+	g.stack = stack
+	var pending []pendingTrigger
+	for _, t := range g.pendingTriggers {
+		if t.controller != i {
+			pending = append(pending, t)
+		}
+	}
+	g.pendingTriggers = pending
+	// if the player with priority left, the next player in turn order receives it
+	g.numPasses = 0
+	if g.priorityPlayer == i && !g.isOver() {
+		g.priorityPlayer = g.nextPlayer(i)
+	}
+}
+
+func (g *game) targetName(t effectTarget) string {
+	if t.isPermanent() {
+		if c, _ := g.findPermanent(t.id); c != nil {
+			return c.card.getName()
+		}
+		return "a missing permanent"
+	}
+	if t.ttype == targetSpell {
+		if i := g.findOnStack(t.id); i != -1 {
+			return g.stack[i].getName()
+		}
+		return "a missing spell"
+	}
+	return g.getPlayer(int(t.index)).name
+}
+
+// isLegalTarget checks whether a target chosen for a spell or ability
+// controlled by controller still exists and is still valid
+func (g *game) isLegalTarget(controller int, t effectTarget) bool {
+	if t.ttype.isUntargeted() {
+		return true
+	}
+	if t.isPermanent() {
+		c, _ := g.findPermanent(t.id)
+		if c == nil {
+			return false
+		}
+		switch t.ttype {
+		case targetPermanent:
+			return true
+		case targetCreature:
+			_, ok := c.card.(*creature)
+			return ok
+		case anyTarget:
+			_, ok := c.card.(*creature)
+			return ok || isPlaneswalker(c.card)
+		case targetPlayerOrPlaneswalker:
+			return isPlaneswalker(c.card)
+		case targetCreatureYouControl:
+			_, ok := c.card.(*creature)
+			_, cController := g.findPermanent(t.id)
+			return ok && cController == controller
+		}
+		return false
+	}
+	switch t.ttype {
+	case targetPlayer, anyTarget, targetPlayerOrPlaneswalker:
+		return int(t.index) < len(g.players) && !g.getPlayer(int(t.index)).lost
+	case targetSpell:
+		i := g.findOnStack(t.id)
+		return i != -1 && isSpell(g.stack[i])
+	}
+	return false
+}
+
+func (g *game) legalTargets(controller int, targets []effectTarget) []effectTarget {
+	legal := []effectTarget{}
+	for _, t := range targets {
+		if g.isLegalTarget(controller, t) {
+			legal = append(legal, t)
+		}
+	}
+	return legal
 }
 
 func (g *game) getActivePlayer() *player {
@@ -203,24 +456,36 @@ func (g *game) getPriorityPlayer() *player {
 }
 
 func (g *game) advancePriority() {
-	g.priorityPlayer = (g.priorityPlayer + 1) % g.numPlayers
+	g.priorityPlayer = g.nextPlayer(g.priorityPlayer)
 }
 
 func (g *game) nextDecisionPoint() {
 	for {
+		// 800.4a the turn of a player who left the game continues without an active player,
+		// so there is nobody to attack: the combat phase is skipped
+		if g.getActivePlayer().lost && g.isCombatPhase() {
+			g.endOfCombatStep()
+			g.nextStep()
+			continue
+		}
 		switch g.currentStep {
 		case untapStep:
+			// 502.4 No player receives priority during the untap step
 			g.untapStep()
-			return
 		case upkeepStep:
-			break //skip
+			g.emit(event{etype: beginningOfUpkeep, player: g.activePlayer})
+			if g.playersCanAct() {
+				return
+			}
 		case drawStep:
 			g.drawStep()
-			return
-		case precombatMainPhase:
-			return
-		case beginningOfCombatStep:
-			break //skip
+			if g.playersCanAct() {
+				return
+			}
+		case precombatMainPhase, beginningOfCombatStep:
+			if g.playersCanAct() {
+				return
+			}
 		case declareAttackersStep:
 			if g.declarations == 0 {
 				// active player declares attackers
@@ -230,33 +495,49 @@ func (g *game) nextDecisionPoint() {
 			// triggered abilities that trigger off attackers being declared trigger
 			return
 		case declareBlockersStep:
-			// if no attackers, skip
+			// 508.8 If no creatures are declared as attackers [...],
+			// the declare blockers and combat damage steps are skipped.
 			if g.numAttackers == 0 {
 				break
 			}
-			break //skip
+			// defending player declares blockers,
+			// or blockers have been declared and we continue this step
+			return
 		case combatDamageFirstStrikeStep:
-			// if no attackers, skip
-			if g.numAttackers == 0 {
+			// if no attackers or no first strike, skip
+			if g.numAttackers == 0 || !g.hasFirstStrikeStep() {
 				break
 			}
-			break //skip
+			g.combatDamageFirstStrikeStep()
+			if g.playersCanAct() {
+				return
+			}
 		case combatDamageStep:
 			// if no attackers, skip
 			if g.numAttackers == 0 {
 				break
 			}
 			g.combatDamageStep()
-			return
+			if g.playersCanAct() {
+				return
+			}
 		case endOfCombatStep:
 			g.endOfCombatStep()
-			return
-		case postcombatMainPhase:
-			return
-		case endStep:
-			break //skip
+			if g.playersCanAct() {
+				return
+			}
+		case postcombatMainPhase, endStep:
+			if g.playersCanAct() {
+				return
+			}
 		case cleanupStep:
-			break //skip
+			g.cleanupStep()
+			// 514.3a At this point, the game checks to see if any state-based actions would be performed
+			// and/or any triggered abilities are waiting to be put onto the stack. If so, [...]
+			// the active player gets priority.
+			if g.performStateBasedActions() || len(g.pendingTriggers) > 0 {
+				return
+			}
 		}
 		// just passed past the cleanup into next turn
 		if g.currentStep == cleanupStep {
@@ -264,9 +545,43 @@ func (g *game) nextDecisionPoint() {
 		}
 		g.nextStep()
 	}
-	return
 }
 
+// playersCanAct decides whether players receive priority in the current step.
+// 117.3a The active player receives priority at the beginning of most steps and phases.
+// As a fast path, if no player can do anything but pass, the step ends as if they all passed.
+// 117.5 State-based actions are checked first, since players would receive priority;
+// anything that happens as a result, such as a player losing or an ability triggering,
+// gives players priority.
+func (g *game) playersCanAct() bool {
+	if len(g.checkStateBasedActions()) > 0 || len(g.stack) > 0 {
+		return true
+	}
+	for _, i := range g.apnapOrder() {
+		if g.canActWithPriority(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// canActWithPriority checks whether player i could do anything but pass if they held priority.
+// Legality depends on who holds priority, i.e. instantSpeed, so it is handed to them temporarily.
+// Mana left in the pool empties when the step ends, so tapping for mana alone doesn't count.
+func (g *game) canActWithPriority(i int) bool {
+	priorityPlayer := g.priorityPlayer
+	g.priorityPlayer = i
+	defer func() { g.priorityPlayer = priorityPlayer }()
+	for _, a := range getActions(g, i) {
+		if _, ok := a.(passAction); ok || g.isManaActivation(a) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// 502.3 the active player untaps all their permanents
 func (g *game) untapStep() {
 	activePlayer := g.getActivePlayer()
 	for i, l := range activePlayer.battlefield.lands {
@@ -279,51 +594,450 @@ func (g *game) untapStep() {
 		c.tapped = false
 		activePlayer.battlefield.creatures[i] = c
 	}
+	for i, c := range activePlayer.battlefield.other {
+		c.tapped = false
+		activePlayer.battlefield.other[i] = c
+	}
 }
 
 func (g *game) drawStep() {
-	g.getActivePlayer().draw()
+	// 103.8a In a two-player game, the player who plays first skips the draw step of their first turn.
+	// 103.8c In all other multiplayer games, no player skips the draw step of their first turn.
+	if g.numPlayers == 2 && g.turn == 1 && g.activePlayer == g.startingPlayer {
+		return
+	}
+	g.drawCards(g.activePlayer, 1)
+}
+
+// 510.4 If at least one attacking or blocking creature has first strike or double strike
+// as the combat damage step begins, the only creatures that assign combat damage in that step
+// are those with first strike or double strike. [...] After that step, instead of proceeding
+// to the end of combat step, the phase gets a second combat damage step.
+func (g *game) hasFirstStrikeStep() bool {
+	for _, p := range g.players {
+		for _, c := range p.battlefield.creatures {
+			if c.attacking == -1 && c.blocking == 0 {
+				continue
+			}
+			if g.hasKeyword(c, firstStrike) || g.hasKeyword(c, doubleStrike) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *game) combatDamageFirstStrikeStep() {
+	g.combatDamage(true)
 }
 
 func (g *game) combatDamageStep() {
+	g.combatDamage(false)
+}
+
+// 702.7b / 702.4b creatures with first strike only deal damage in the first strike step,
+// creatures with double strike in both steps
+func (g *game) dealsCombatDamage(c cardInstance, firstStrikeStep bool) bool {
+	ks := g.characteristics(c).keywords
+	if firstStrikeStep {
+		return ks.has(firstStrike) || ks.has(doubleStrike)
+	}
+	return !ks.has(firstStrike) || ks.has(doubleStrike)
+}
+
+// combat damage assigned by a source, dealt once all combat damage has been assigned
+type combatDamageAssignment struct {
+	source           cardInstance
+	sourceController int
+	// to: id of the creature or planeswalker assigned damage,
+	// 0 for the player or planeswalker an unblocked attacker is attacking
+	to     uint64
+	amount int
+}
+
+// 510.1 Each attacking and each blocking creature assigns combat damage
+// equal to its power. 510.2 All combat damage is dealt simultaneously:
+// damage is only dealt once every creature has assigned its damage,
+// so power is checked before any of it changes, i.e. by -1/-1 counters from infect.
+func (g *game) combatDamage(firstStrikeStep bool) {
 	activePlayer := g.getActivePlayer()
-	for i, c := range activePlayer.battlefield.creatures {
-		if c.attacking == -1 {
+	assignments := []combatDamageAssignment{}
+	for _, c := range activePlayer.battlefield.creatures {
+		if c.attacking == -1 || !g.dealsCombatDamage(c, firstStrikeStep) {
 			continue
 		}
 		defendingPlayer := g.getPlayer(c.attacking)
-		defendingPlayer.lifeTotal -= c.card.(*creature).power
-		c.attacking = -1
-		activePlayer.battlefield.creatures[i] = c
+		power := g.power(c)
+		if !c.blocked {
+			assignments = append(assignments, combatDamageAssignment{source: c, sourceController: g.activePlayer, amount: power})
+			continue
+		}
+		// 510.1c A blocked creature assigns its combat damage to the creatures blocking it.
+		// lethal damage to each blocker in order, the remainder to the last one.
+		// a blocked creature whose blockers are all removed assigns no damage.
+		blockers := []uint64{}
+		for _, b := range defendingPlayer.battlefield.creatures {
+			if b.blocking == c.id {
+				blockers = append(blockers, b.id)
+			}
+		}
+		hasTrample := g.hasKeyword(c, trample)
+		for n, id := range blockers {
+			if power <= 0 {
+				break
+			}
+			b := defendingPlayer.permanent(id)
+			// 702.2c any nonzero amount of damage from a source with deathtouch is lethal
+			assign := g.toughness(*b) - b.damage
+			if g.hasKeyword(c, deathtouch) && !b.deathtouched {
+				assign = 1
+			}
+			if assign < 0 {
+				assign = 0
+			}
+			if assign > power || (n == len(blockers)-1 && !hasTrample) {
+				assign = power
+			}
+			power -= assign
+			assignments = append(assignments, combatDamageAssignment{source: c, sourceController: g.activePlayer, to: id, amount: assign})
+		}
+		// 702.19b trample assigns the rest of its damage to the player,
+		// 702.19e even if all creatures blocking it are removed from combat
+		if hasTrample && power > 0 {
+			assignments = append(assignments, combatDamageAssignment{source: c, sourceController: g.activePlayer, amount: power})
+		}
+	}
+	// 510.1d A blocking creature assigns combat damage to the creature it's blocking.
+	for _, i := range g.defendingPlayers() {
+		for _, b := range g.getPlayer(i).battlefield.creatures {
+			if b.blocking == 0 || !g.dealsCombatDamage(b, firstStrikeStep) {
+				continue
+			}
+			if activePlayer.permanent(b.blocking) == nil {
+				continue
+			}
+			assignments = append(assignments, combatDamageAssignment{source: b, sourceController: i, to: b.blocking, amount: g.power(b)})
+		}
+	}
+	for _, a := range assignments {
+		if a.to == 0 {
+			g.combatDamageToDefender(a.source, a.amount)
+			continue
+		}
+		c, controller := g.findPermanent(a.to)
+		if c == nil {
+			continue
+		}
+		g.combatDamageToCreature(a.source, a.sourceController, c, controller, a.amount)
 	}
 }
 
+// 510.1b An unblocked creature assigns its combat damage to the player or planeswalker it's attacking.
+// If it's attacking a planeswalker that has left the battlefield, it assigns no combat damage.
+func (g *game) combatDamageToDefender(c cardInstance, amount int) {
+	if c.attackingPlaneswalker == 0 {
+		g.combatDamageToPlayer(c, g.activePlayer, c.attacking, amount)
+		return
+	}
+	pw, controller := g.findPermanent(c.attackingPlaneswalker)
+	if pw == nil {
+		return
+	}
+	g.combatDamageToCreature(c, g.activePlayer, pw, controller, amount)
+}
+
+func (g *game) combatDamageToPlayer(source cardInstance, sourceController, player, amount int) {
+	if amount <= 0 {
+		return
+	}
+	dealt := g.dealDamage(event{etype: damageDealt, player: player, amount: amount, combat: true, infect: g.hasKeyword(source, infect)})
+	g.trackCommanderDamage(source, player, dealt)
+	g.lifelink(source, sourceController, dealt)
+}
+
+func (g *game) combatDamageToCreature(source cardInstance, sourceController int, c *cardInstance, controller, amount int) {
+	if amount <= 0 {
+		return
+	}
+	dealt := g.dealDamage(event{etype: damageDealt, player: controller, id: c.id, card: c.card, amount: amount, combat: true, infect: g.hasKeyword(source, infect)})
+	if dealt > 0 && g.hasKeyword(source, deathtouch) {
+		c.deathtouched = true
+	}
+	g.lifelink(source, sourceController, dealt)
+}
+
+// 702.15b Damage dealt by a source with lifelink causes that source's controller
+// to gain that much life (in addition to any other results that damage causes).
+func (g *game) lifelink(source cardInstance, controller, amount int) {
+	if amount > 0 && g.hasKeyword(source, lifelink) {
+		g.gainLife(controller, amount)
+	}
+}
+
+const maxHandSize = 7
+
+// 701.8a To discard a card, move it from its owner's hand to that player's graveyard.
+func (g *game) discard(i int, c Card) {
+	g.moveCard(cardInstance{card: c, owner: i}, zoneHand, zoneGraveyard)
+}
+
+// 511.3 As soon as the end of combat step ends, all creatures
+// are removed from combat.
 func (g *game) endOfCombatStep() {
 	g.numAttackers = 0
+	for _, p := range g.players {
+		for i, c := range p.battlefield.creatures {
+			c.attacking = -1
+			c.attackingPlaneswalker = 0
+			c.blocking = 0
+			c.blocked = false
+			p.battlefield.creatures[i] = c
+		}
+	}
+}
+
+// 514.1 First, if the active player's hand contains more cards than their maximum hand size
+// (normally seven), they discard enough cards to reduce their hand size to that number.
+// 514.2 Second, all damage marked on permanents is removed and all
+// "until end of turn" and "this turn" effects end
+func (g *game) cleanupStep() {
+	active := g.getActivePlayer()
+	if n := active.hand.size() - maxHandSize; n > 0 && !active.lost {
+		for _, c := range active.strategy.Discard(active, g, n) {
+			g.discard(g.activePlayer, c)
+		}
+	}
+	for _, p := range g.players {
+		for i, c := range p.battlefield.creatures {
+			c.damage = 0
+			c.deathtouched = false
+			p.battlefield.creatures[i] = c
+		}
+		for i, c := range p.battlefield.other {
+			c.loyaltyActivated = false
+			p.battlefield.other[i] = c
+		}
+	}
+	g.endUntilEndOfTurnEffects()
+	g.endUntilEndOfTurnReplacements()
 }
 
 func (g *game) nextStep() {
 	g.declarations = 0
+	// 500.4 When a step or phase ends, any unused mana left in a player's mana pool empties.
+	for _, p := range g.players {
+		p.manaPool = mana{}
+	}
 	g.currentStep = (g.currentStep + 1) % numSteps
 }
 
+// 500.1 turns pass to the next player in turn order who is still in the game;
+// a new turn number starts whenever the turn passes the starting player's seat
 func (g *game) nextTurn() {
 	g.getActivePlayer().landPlayed = false
-	g.activePlayer = (g.activePlayer + 1) % g.numPlayers
-	// TODO: should check statebased actions here too!
-	g.advancePriority()
-	if g.activePlayer == g.startingPlayer {
-		g.turn++
+	next := g.nextPlayer(g.activePlayer)
+	for i := g.activePlayer + 1; ; i++ {
+		if i%g.numPlayers == g.startingPlayer {
+			g.turn++
+		}
+		if i%g.numPlayers == next {
+			break
+		}
 	}
+	g.activePlayer = next
+	g.priorityPlayer = next
 }
 
-func (g *game) checkStateBasedActions() (gameEnds bool) {
+// 704.3 Whenever a player would get priority, the game checks for any of the listed conditions
+// for state-based actions, then performs all applicable state-based actions simultaneously
+// as a single event. If any state-based actions are performed as a result of a check,
+// the check is repeated; otherwise all triggered abilities that are waiting to be put
+// on the stack are put on the stack, then the check is repeated.
+// Returns the players that lose the game; it is up to the caller to remove them.
+func (g *game) checkStateBasedActions() (losers []int) {
+	for {
+		for g.performStateBasedActions() {
+		}
+		if !g.putTriggersOnStack() {
+			break
+		}
+	}
+	return g.losingPlayers()
+}
+
+// losingPlayers checks 704.5a-c without changing the game state
+func (g *game) losingPlayers() []int {
+	losers := []int{}
+	for i, p := range g.players {
+		if p.lost {
+			continue
+		}
+		if p.losesGame() {
+			losers = append(losers, i)
+		}
+	}
+	return losers
+}
+
+// performStateBasedActions does a single pass, returning whether anything happened
+func (g *game) performStateBasedActions() bool {
+	toGraveyard := map[uint64]struct{}{}
+	performed := false
 	for _, p := range g.players {
-		if p.lifeTotal <= 0 || p.decked {
-			return true
+		for i, c := range p.battlefield.creatures {
+			toughness := g.toughness(c)
+			// 704.5f If a creature has toughness 0 or less, it's put into its owner's graveyard.
+			if toughness <= 0 {
+				toGraveyard[c.id] = struct{}{}
+				continue
+			}
+			// 704.5g A creature with toughness greater than 0 that has damage marked on it
+			// equal to or greater than its toughness has been dealt lethal damage and is destroyed.
+			if c.damage >= toughness {
+				toGraveyard[c.id] = struct{}{}
+			}
+			// 704.5h A creature with toughness greater than 0 that has been dealt damage
+			// by a source with deathtouch since the last time state-based actions were checked is destroyed.
+			if c.deathtouched {
+				toGraveyard[c.id] = struct{}{}
+				c.deathtouched = false
+				p.battlefield.creatures[i] = c
+			}
+		}
+		// 704.5q If a permanent has both a +1/+1 counter and a -1/-1 counter on it,
+		// N +1/+1 and N -1/-1 counters are removed from it
+		for _, list := range [][]cardInstance{p.battlefield.lands, p.battlefield.creatures, p.battlefield.other} {
+			for i := range list {
+				c := &list[i]
+				n := minInt(c.counters[plusOneCounter], c.counters[minusOneCounter])
+				if n > 0 {
+					c.addCounters(plusOneCounter, -n)
+					c.addCounters(minusOneCounter, -n)
+					performed = true
+				}
+			}
+		}
+		// 704.5d If a token is in a zone other than the battlefield, it ceases to exist.
+		if p.removeTokens() {
+			performed = true
+		}
+		// 704.5i If a planeswalker has loyalty 0, it's put into its owner's graveyard.
+		for _, c := range p.battlefield.other {
+			if isPlaneswalker(c.card) && c.counters[loyaltyCounter] <= 0 {
+				toGraveyard[c.id] = struct{}{}
+			}
+		}
+		// 704.5j If a player controls two or more legendary permanents with the same name,
+		// that player chooses one of them, and the rest are put into their owners' graveyards.
+		legends := map[string][]cardInstance{}
+		names := []string{}
+		for _, c := range p.permanents() {
+			if !c.card.isLegendary() {
+				continue
+			}
+			name := c.card.getName()
+			if _, ok := legends[name]; !ok {
+				names = append(names, name)
+			}
+			legends[name] = append(legends[name], c)
+		}
+		for _, name := range names {
+			if len(legends[name]) < 2 {
+				continue
+			}
+			keep := p.strategy.ChooseLegend(p, g, legends[name])
+			for _, c := range legends[name] {
+				if c.id != keep {
+					toGraveyard[c.id] = struct{}{}
+				}
+			}
+		}
+		// 704.5m If an Aura is attached to an illegal object or player, or is not attached
+		// to an object or player, that Aura is put into its owner's graveyard.
+		// 704.5n If an Equipment or Fortification is attached to an illegal permanent or to a player,
+		// it becomes unattached from that permanent or player. It remains on the battlefield.
+		for j, c := range p.battlefield.other {
+			switch card := c.card.(type) {
+			case *aura:
+				if !g.isLegalAttachment(c.attachedTo, card.enchant) {
+					toGraveyard[c.id] = struct{}{}
+				}
+			case *equipment:
+				if c.attachedTo != 0 && !g.isLegalAttachment(c.attachedTo, targetCreature) {
+					c.attachedTo = 0
+					p.battlefield.other[j] = c
+					performed = true
+				}
+			}
 		}
 	}
-	return false
+	for _, p := range g.players {
+		for _, c := range p.permanents() {
+			if _, ok := toGraveyard[c.id]; !ok {
+				continue
+			}
+			g.moveCard(c, zoneBattlefield, zoneGraveyard)
+		}
+	}
+	return performed || len(toGraveyard) > 0
+}
+
+// 303.4d an aura's enchant ability restricts what it can be attached to;
+// equipment can only be attached to creatures
+func (g *game) isLegalAttachment(id uint64, t targetType) bool {
+	c, _ := g.findPermanent(id)
+	if c == nil {
+		return false
+	}
+	if t == targetPermanent {
+		return true
+	}
+	_, ok := c.card.(*creature)
+	return ok
+}
+
+// findPermanent looks up a permanent by cardInstance.id across all battlefields,
+// returning it together with the index of its controller, or nil if it is gone
+func (g *game) findPermanent(id uint64) (*cardInstance, int) {
+	for i, p := range g.players {
+		if c := p.permanent(id); c != nil {
+			return c, i
+		}
+	}
+	return nil, -1
+}
+
+// findOnStack looks up a spell or ability by its id, returning its index in the stack or -1 if it is gone
+func (g *game) findOnStack(id uint64) int {
+	for i, o := range g.stack {
+		if o.getID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// removePermanent takes a permanent off any battlefield,
+// returning it together with the index of its controller
+func (g *game) removePermanent(id uint64) (cardInstance, int) {
+	for i, p := range g.players {
+		if c, ok := p.removePermanent(id); ok {
+			return c, i
+		}
+	}
+	panic(fmt.Sprintf("permanent %d not found", id))
+}
+
+// 104.2a A player still in the game wins the game if that player's opponents
+// have all left the game.
+func (g *game) isOver() bool {
+	remaining := 0
+	for _, p := range g.players {
+		if !p.lost {
+			remaining++
+		}
+	}
+	return remaining <= 1
 }
 
 func (g *game) copy() *game {
@@ -333,10 +1047,22 @@ func (g *game) copy() *game {
 	for i, p := range g.players {
 		newG.players[i] = p.copy()
 	}
+	if len(g.continuousEffects) != 0 {
+		newG.continuousEffects = make([]activeEffect, len(g.continuousEffects))
+		copy(newG.continuousEffects, g.continuousEffects)
+	}
+	if len(g.replacementEffects) != 0 {
+		newG.replacementEffects = make([]activeReplacement, len(g.replacementEffects))
+		copy(newG.replacementEffects, g.replacementEffects)
+	}
+	if len(g.pendingTriggers) != 0 {
+		newG.pendingTriggers = make([]pendingTrigger, len(g.pendingTriggers))
+		copy(newG.pendingTriggers, g.pendingTriggers)
+	}
 	if len(g.stack) == 0 {
 		return newG
 	}
-	newG.stack = make([]cardAction, len(g.stack))
+	newG.stack = make([]stackObject, len(g.stack))
 	for i, a := range g.stack {
 		newG.stack[i] = a
 	}
@@ -345,24 +1071,40 @@ func (g *game) copy() *game {
 
 func (g *game) debug() {
 	activePlayer := g.getActivePlayer()
-	opp := g.getOpponent(g.activePlayer)
 	fmt.Println("----------------------------------------------------------------")
 	fmt.Printf("%s turn %d step %s: %s \n", activePlayer.name, g.turn, stepName(g.currentStep), activePlayer.String())
-	fmt.Printf("           VS %s: %s \n", opp.name, opp.String())
+	for _, i := range g.getOpponents(g.activePlayer) {
+		opp := g.getPlayer(i)
+		fmt.Printf("           VS %s: %s \n", opp.name, opp.String())
+	}
 }
 
 func (g *game) play(a cardAction) {
 	p := g.getPlayer(a.controller)
-
-	// remove card from players hand
-	p.hand[a.card] -= 1
-	if p.hand[a.card] == 0 {
-		delete(p.hand, a.card)
+	cost := g.manaCost(a.controller, a.card)
+	from := zoneHand
+	if a.fromCommandZone {
+		from = zoneCommand
+		cost = cost.add(p.commanderTax())
+		p.commanderCasts++
 	}
 
-	p.strategy.PayManaCost(p, a.card.getManaCost())
+	g.moveCard(cardInstance{card: a.card, owner: a.controller}, from, zoneStack)
+
+	g.payManaCost(p, cost)
 
+	a.id = rand.Uint64()
 	g.stack = append(g.stack, a)
+	if isSpell(a) {
+		g.emit(event{etype: spellCast, player: a.controller, card: a.card})
+	}
+}
+
+// 305.2 A player can normally play one land during their turn
+func (g *game) playLand(a playLandAction) {
+	p := g.getPlayer(a.controller)
+	p.landPlayed = true
+	g.moveCard(cardInstance{card: a.card, owner: a.controller}, zoneHand, zoneBattlefield)
 }
 
 func (g *game) resolve() {
@@ -371,28 +1113,206 @@ func (g *game) resolve() {
 	}
 	a := g.stack[len(g.stack)-1]
 	g.stack = g.stack[:len(g.stack)-1]
-	a.card.resolve(g, a)
+	a.resolve(g)
+}
+
+// 602.2 paying costs and putting the ability on the stack.
+// 605.3b Mana abilities don't use the stack; they resolve immediately.
+func (g *game) activate(a activateAction) {
+	p := g.getPlayer(a.controller)
+	source := p.permanent(a.id)
+	aa := source.card.getActivatedAbilities()[a.index]
+	card := source.card
+	if aa.cost.tap {
+		source.tapped = true
+	}
+	p.lifeTotal -= aa.cost.life
+	if aa.cost.loyaltyAbility {
+		source.addCounters(loyaltyCounter, aa.cost.loyalty)
+		source.loyaltyActivated = true
+	}
+	if aa.cost.mana.converted() > 0 {
+		g.payManaCost(p, aa.cost.mana)
+	}
+	if aa.cost.sacrifice {
+		g.moveCard(*source, zoneBattlefield, zoneGraveyard)
+	}
+	if aa.isManaAbility() {
+		// 106.4 When an effect instructs a player to add mana, that mana goes into their mana pool.
+		aa.getEffect().apply(g, []effectTarget{{index: target(a.controller), ttype: you}})
+		return
+	}
+	g.stack = append(g.stack, abilityOnStack{
+		action:   a.action,
+		id:       rand.Uint64(),
+		source:   card,
+		sourceID: a.id,
+		ability:  aa,
+		targets:  a.targets,
+	})
+}
+
+// 601.2g-h The player has a chance to activate mana abilities, then pays the total cost.
+// Strategies activate mana abilities in PayManaCost, which adds mana to the pool,
+// and the cost is then paid from the pool, using up any mana that was floating.
+func (g *game) payManaCost(p *player, cost mana) {
+	p.strategy.PayManaCost(p, g, cost)
+	if !p.manaPool.covers(cost) {
+		panic("mana pool does not cover the cost")
+	}
+	p.manaPool = p.manaPool.pay(cost)
+}
+
+// isManaActivation checks whether the action activates a mana ability
+func (g *game) isManaActivation(action Action) bool {
+	a, ok := action.(activateAction)
+	if !ok {
+		return false
+	}
+	c, _ := g.findPermanent(a.id)
+	return c != nil && c.card.getActivatedAbilities()[a.index].isManaAbility()
+}
+
+// isLegalManaActivation checks a mana ability activated explicitly while holding priority
+func (g *game) isLegalManaActivation(a activateAction) bool {
+	p := g.getPlayer(a.controller)
+	c := p.permanent(a.id)
+	return c != nil && p.canActivateManaAbility(g, *c, a.index)
+}
+
+// activateManaAbility taps a source for mana, adding it to the player's mana pool
+func (g *game) activateManaAbility(i int, id uint64, index int) {
+	g.activate(activateAction{action: action{controller: i}, id: id, index: index})
+}
+
+// 112.1 A spell is a card on the stack; lands never go on the stack, see playLandAction
+func isSpell(o stackObject) bool {
+	_, ok := o.(cardAction)
+	return ok
+}
+
+// 508.1b [...] In a multiplayer game, the active player chooses which of their opponents
+// or which planeswalker those opponents control each creature attacks.
+func (g *game) isLegalAttack(a attackAction) bool {
+	for _, att := range a.attackers {
+		if !containsPlayer(g.getOpponents(a.controller), att.target) {
+			return false
+		}
+		if att.planeswalker == 0 {
+			continue
+		}
+		if pw, controller := g.findPermanent(att.planeswalker); pw == nil || controller != att.target {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPlayer(players []int, i int) bool {
+	for _, j := range players {
+		if j == i {
+			return true
+		}
+	}
+	return false
 }
 
 func (g *game) declareAttackers(a attackAction) {
+	if !g.isLegalAttack(a) {
+		panic("illegal attack")
+	}
 	p := g.getPlayer(a.getController())
 	for _, att := range a.attackers {
-		attacker := p.battlefield.creatures[att.index]
+		attacker := p.permanent(att.id)
 		attacker.attacking = att.target
-		attacker.tapped = true
-		p.battlefield.creatures[att.index] = attacker
+		attacker.attackingPlaneswalker = att.planeswalker
+		// 702.20b Attacking doesn't cause creatures with vigilance to tap.
+		if !g.hasKeyword(*attacker, vigilance) {
+			attacker.tapped = true
+		}
+		g.emit(event{etype: attacks, player: a.controller, id: attacker.id, card: attacker.card})
 	}
 	g.numAttackers = len(a.attackers)
 }
 
+// 509.1a The defending player chooses which creatures they control, if any, will block.
+// The chosen creatures must be untapped.
+// 509.1b [...] each creature can block only one attacker unless stated otherwise.
+func (g *game) isLegalBlock(a blockAction) bool {
+	p := g.getPlayer(a.getController())
+	activePlayer := g.getActivePlayer()
+	blocking := map[uint64]struct{}{}
+	numBlockers := map[uint64]int{}
+	for _, b := range a.blockers {
+		if _, ok := blocking[b.id]; ok {
+			return false
+		}
+		blocking[b.id] = struct{}{}
+		blocker := p.permanent(b.id)
+		if blocker == nil || blocker.tapped {
+			return false
+		}
+		if _, ok := blocker.card.(*creature); !ok {
+			return false
+		}
+		if g.characteristics(*blocker).cantBlock {
+			return false
+		}
+		attacker := activePlayer.permanent(b.blocks)
+		if attacker == nil || attacker.attacking != p.idx {
+			return false
+		}
+		// 702.9b A creature with flying can't be blocked except by creatures with flying and/or reach.
+		if g.hasKeyword(*attacker, flying) && !g.hasKeyword(*blocker, flying) && !g.hasKeyword(*blocker, reach) {
+			return false
+		}
+		numBlockers[b.blocks]++
+	}
+	// 702.111b A creature with menace can't be blocked except by two or more creatures.
+	for id, n := range numBlockers {
+		if n == 1 && g.hasKeyword(*activePlayer.permanent(id), menace) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *game) declareBlockers(a blockAction) {
+	if !g.isLegalBlock(a) {
+		panic("illegal block")
+	}
+	p := g.getPlayer(a.getController())
+	activePlayer := g.getActivePlayer()
+	for _, b := range a.blockers {
+		p.permanent(b.id).blocking = b.blocks
+		activePlayer.permanent(b.blocks).blocked = true
+	}
+}
+
 func (g *game) isMainPhase() bool {
 	return g.currentStep == precombatMainPhase || g.currentStep == postcombatMainPhase
 }
 
+func (g *game) isCombatPhase() bool {
+	return g.currentStep >= beginningOfCombatStep && g.currentStep <= endOfCombatStep
+}
+
+// decisionPlayer is the player who has to act next: usually the player
+// with priority, but the defending players declare blockers.
+func (g *game) decisionPlayer() int {
+	if g.declaringBlockers() {
+		return g.defendingPlayers()[g.declarations]
+	}
+	return g.priorityPlayer
+}
+
 func (g *game) getPlayerAction() Action {
-	p := g.players[g.priorityPlayer]
-	if g.currentStep == declareAttackersStep && g.declarations == 0 {
+	p := g.players[g.decisionPlayer()]
+	if g.declaringAttackers() {
 		return p.strategy.Attacks(p, g)
 	}
+	if g.declaringBlockers() {
+		return p.strategy.Blocks(p, g)
+	}
 	return p.strategy.NextAction(p, g)
 }
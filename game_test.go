@@ -21,7 +21,7 @@ func testManaAvailable(n int) battlefield {
 func testManaTapUntap(t, u int) battlefield {
 	lands := make([]cardInstance, t+u)
 	for i := 0; i < u; i++ {
-		lands[i] = cardInstance{card: mountain}
+		lands[i] = instanceOf(mountain)
 	}
 	for i := u; i < t+u; i++ {
 		land := instanceOf(mountain)
@@ -32,13 +32,26 @@ func testManaTapUntap(t, u int) battlefield {
 }
 
 func ignoreInstanceIDs(g *game) {
-	// set cardinstance ids to 0 because we dont care about them
+	// set cardinstance ids and timestamps to 0 because we dont care about them
+	g.timestamp = 0
+	for i, o := range g.stack {
+		switch so := o.(type) {
+		case cardAction:
+			so.id = 0
+			g.stack[i] = so
+		case abilityOnStack:
+			so.id = 0
+			g.stack[i] = so
+		}
+	}
 	for i, ci := range g.players[SELF].battlefield.lands {
 		ci.id = 0
+		ci.timestamp = 0
 		g.players[SELF].battlefield.lands[i] = ci
 	}
 	for i, ci := range g.players[OPP].battlefield.lands {
 		ci.id = 0
+		ci.timestamp = 0
 		g.players[OPP].battlefield.lands[i] = ci
 	}
 }
@@ -107,7 +120,7 @@ func TestResolveAction(t *testing.T) {
 				priorityPlayer: SELF,
 				activePlayer:   SELF,
 				currentStep:    precombatMainPhase,
-				stack: []cardAction{
+				stack: []stackObject{
 					cardAction{
 						card:    lavaSpike,
 						action:  action{controller: SELF},
@@ -133,7 +146,7 @@ func TestResolveAction(t *testing.T) {
 				activePlayer:   SELF,
 				currentStep:    precombatMainPhase,
 			},
-			action: cardAction{
+			action: playLandAction{
 				card:   mountain,
 				action: action{controller: SELF},
 			},
@@ -150,7 +163,42 @@ func TestResolveAction(t *testing.T) {
 				priorityPlayer: SELF,
 				activePlayer:   SELF,
 				currentStep:    precombatMainPhase,
-				stack:          []cardAction{},
+			},
+		},
+		{
+			name: "play island without using the stack",
+			game: &game{
+				players: []*player{
+					SELF: &player{
+						hand: map[Card]int{
+							island: 1,
+						},
+						strategy: goldfish{},
+					},
+					OPP: &player{strategy: goldfish{}},
+				},
+				priorityPlayer: SELF,
+				activePlayer:   SELF,
+				currentStep:    precombatMainPhase,
+				numPasses:      1,
+			},
+			action: playLandAction{
+				card:   island,
+				action: action{controller: SELF},
+			},
+			want: &game{
+				players: []*player{
+					SELF: &player{
+						hand:        map[Card]int{},
+						battlefield: battlefield{lands: []cardInstance{{card: island}}},
+						landPlayed:  true,
+						strategy:    goldfish{},
+					},
+					OPP: &player{strategy: goldfish{}},
+				},
+				priorityPlayer: SELF,
+				activePlayer:   SELF,
+				currentStep:    precombatMainPhase,
 			},
 		},
 	} {
@@ -177,8 +225,8 @@ func TestResolveVSGoldfish(t *testing.T) {
 			name: "pass action",
 			game: &game{
 				players: []*player{
-					SELF: &player{strategy: goldfish{}},
-					OPP:  &player{strategy: goldfish{}},
+					SELF: &player{lifeTotal: 20, strategy: goldfish{}},
+					OPP:  &player{lifeTotal: 20, strategy: goldfish{}},
 				},
 				priorityPlayer: SELF,
 				activePlayer:   SELF,
@@ -187,15 +235,59 @@ func TestResolveVSGoldfish(t *testing.T) {
 			actions: []Action{passAction{action{controller: SELF}}},
 			want: &game{
 				players: []*player{
-					SELF: &player{strategy: goldfish{}},
-					OPP:  &player{strategy: goldfish{}},
+					SELF: &player{lifeTotal: 20, strategy: goldfish{}},
+					OPP:  &player{lifeTotal: 20, strategy: goldfish{}},
 				},
 				priorityPlayer: SELF,
 				activePlayer:   SELF,
-				// TODO: currently skipping over beginningOfCombat
+				// nobody can act in the beginning of combat step, so it is skipped
 				currentStep: declareAttackersStep,
 			},
 		},
+		{
+			name: "priority in the beginning of combat step",
+			game: &game{
+				players: []*player{
+					SELF: &player{lifeTotal: 20, strategy: goldfish{}},
+					OPP:  &player{idx: OPP, lifeTotal: 20, strategy: goldfish{}, hand: unorderedCards{shock: 1}, battlefield: testManaTapUntap(0, 1)},
+				},
+				priorityPlayer: SELF,
+				activePlayer:   SELF,
+				currentStep:    precombatMainPhase,
+			},
+			actions: []Action{passAction{action{controller: SELF}}},
+			want: &game{
+				players: []*player{
+					SELF: &player{lifeTotal: 20, strategy: goldfish{}},
+					OPP:  &player{idx: OPP, lifeTotal: 20, strategy: goldfish{}, hand: unorderedCards{shock: 1}, battlefield: testManaTapUntap(0, 1)},
+				},
+				priorityPlayer: SELF,
+				activePlayer:   SELF,
+				currentStep:    beginningOfCombatStep,
+			},
+		},
+		{
+			name: "priority in the end step",
+			game: &game{
+				players: []*player{
+					SELF: &player{lifeTotal: 20, strategy: goldfish{}},
+					OPP:  &player{idx: OPP, lifeTotal: 20, strategy: goldfish{}, hand: unorderedCards{shock: 1}, battlefield: testManaTapUntap(0, 1)},
+				},
+				priorityPlayer: SELF,
+				activePlayer:   SELF,
+				currentStep:    postcombatMainPhase,
+			},
+			actions: []Action{passAction{action{controller: SELF}}},
+			want: &game{
+				players: []*player{
+					SELF: &player{lifeTotal: 20, strategy: goldfish{}},
+					OPP:  &player{idx: OPP, lifeTotal: 20, strategy: goldfish{}, hand: unorderedCards{shock: 1}, battlefield: testManaTapUntap(0, 1)},
+				},
+				priorityPlayer: SELF,
+				activePlayer:   SELF,
+				currentStep:    endStep,
+			},
+		},
 		{
 			name: "play lava spike",
 			game: &game{
@@ -233,7 +325,7 @@ func TestResolveVSGoldfish(t *testing.T) {
 						lifeTotal: -3,
 					},
 				},
-				stack:          []cardAction{},
+				stack:          []stackObject{},
 				priorityPlayer: SELF,
 				activePlayer:   SELF,
 				currentStep:    precombatMainPhase,
@@ -281,6 +373,29 @@ func TestPlayerHasMana(t *testing.T) {
 			mana: mana{c: 1, r: 1},
 			want: true,
 		},
+		{
+			player: &player{
+				manaPool: mana{b: 1},
+			},
+			mana: mana{b: 1},
+			want: true,
+		},
+		{
+			player: &player{
+				manaPool:    mana{b: 1},
+				battlefield: testManaAvailable(1),
+			},
+			mana: mana{c: 1, r: 1},
+			want: true,
+		},
+		{
+			player: &player{
+				manaPool:    mana{b: 1},
+				battlefield: testManaAvailable(1),
+			},
+			mana: mana{r: 2},
+			want: false,
+		},
 	} {
 		got := tt.player.hasMana(tt.mana)
 		if got != tt.want {
@@ -288,3 +403,1142 @@ func TestPlayerHasMana(t *testing.T) {
 		}
 	}
 }
+
+func TestManaPool(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{
+				lifeTotal:   20,
+				strategy:    goldfish{},
+				hand:        unorderedCards{darkRitual: 1, lavaSpike: 1},
+				battlefield: battlefield{lands: []cardInstance{{id: 1, card: swamp}, {id: 2, card: mountain}}},
+			},
+			OPP: &player{lifeTotal: 20},
+		},
+		numPlayers:  2,
+		currentStep: precombatMainPhase,
+	}
+	p := g.getPlayer(SELF)
+	// 605.3a activating a mana ability while holding priority
+	g.resolveAction(activateAction{action: action{controller: SELF}, id: 1})
+	if p.manaPool != (mana{b: 1}) || !p.permanent(1).tapped {
+		t.Fatalf("swamp should be tapped for floating mana: pool %v", p.manaPool)
+	}
+	// floating mana pays for dark ritual, so the mountain stays untapped
+	g.resolveAction(cardAction{action: action{controller: SELF}, card: darkRitual, targets: []effectTarget{{index: target(SELF), ttype: you}}})
+	if p.manaPool != (mana{}) || p.permanent(2).tapped {
+		t.Fatalf("dark ritual should be paid from the pool: pool %v", p.manaPool)
+	}
+	g.resolve()
+	if p.manaPool != (mana{b: 3}) {
+		t.Fatalf("dark ritual should add mana to the pool: got %v", p.manaPool)
+	}
+	// black mana can't pay for red, so the mountain is tapped
+	g.resolveAction(cardAction{action: action{controller: SELF}, card: lavaSpike, targets: []effectTarget{{index: target(OPP), ttype: targetPlayer}}})
+	if p.manaPool != (mana{b: 3}) || !p.permanent(2).tapped {
+		t.Fatalf("lava spike should be paid by tapping the mountain: pool %v", p.manaPool)
+	}
+	// 500.4 mana empties from the pool between steps
+	g.nextStep()
+	if p.manaPool != (mana{}) {
+		t.Errorf("mana pool should be empty after the step ends: got %v", p.manaPool)
+	}
+}
+
+func TestIllegalManaActivation(t *testing.T) {
+	for i, tt := range []struct {
+		name           string
+		activation     activateAction
+		priorityPlayer int
+	}{
+		{
+			name:       "tapped source",
+			activation: activateAction{action: action{controller: SELF}, id: 2},
+		},
+		{
+			name:       "source controlled by another player",
+			activation: activateAction{action: action{controller: SELF}, id: 3},
+		},
+		{
+			name:           "without priority",
+			activation:     activateAction{action: action{controller: SELF}, id: 1},
+			priorityPlayer: OPP,
+		},
+	} {
+		g := &game{
+			players: []*player{
+				SELF: &player{idx: SELF, battlefield: battlefield{lands: []cardInstance{{id: 1, card: mountain}, {id: 2, card: mountain, tapped: true}}}},
+				OPP:  &player{idx: OPP, battlefield: battlefield{lands: []cardInstance{{id: 3, card: mountain, owner: OPP}}}},
+			},
+			numPlayers:     2,
+			priorityPlayer: tt.priorityPlayer,
+			currentStep:    precombatMainPhase,
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: %s) activation should not be allowed", i, tt.name)
+				}
+			}()
+			g.resolveAction(tt.activation)
+		}()
+	}
+}
+
+func TestUntapArtifact(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{
+				idx:         SELF,
+				strategy:    goldfish{},
+				library:     orderedCards{island, island},
+				battlefield: battlefield{lands: testManaAvailable(4).lands, other: []cardInstance{{id: 1, card: jayemdaeTome}}},
+			},
+			OPP: &player{idx: OPP, strategy: goldfish{}},
+		},
+		numPlayers:  2,
+		currentStep: precombatMainPhase,
+	}
+	p := g.getPlayer(SELF)
+	tome := *p.permanent(1)
+	if !p.canActivate(g, tome, 0) {
+		t.Fatalf("should be able to activate the tome")
+	}
+	g.resolveAction(activateAction{action: action{controller: SELF}, id: 1, targets: []effectTarget{{index: target(SELF), ttype: you}}})
+	g.resolve()
+	if !p.permanent(1).tapped || p.canActivate(g, *p.permanent(1), 0) {
+		t.Fatalf("tome should be tapped after activating it")
+	}
+	// 502.3 the tome untaps in its controller's next untap step
+	g.untapStep()
+	if !p.canActivate(g, *p.permanent(1), 0) {
+		t.Errorf("tome should be untapped and can be activated again")
+	}
+}
+
+func TestManaPay(t *testing.T) {
+	for i, tt := range []struct {
+		pool mana
+		cost mana
+		want mana
+	}{
+		{
+			pool: mana{r: 2},
+			cost: mana{r: 1},
+			want: mana{r: 1},
+		},
+		{
+			pool: mana{c: 1, b: 1, r: 1},
+			cost: mana{c: 1, r: 1},
+			want: mana{b: 1},
+		},
+		{
+			pool: mana{u: 1, b: 2},
+			cost: mana{c: 2, u: 1},
+			want: mana{},
+		},
+	} {
+		if got := tt.pool.pay(tt.cost); got != tt.want {
+			t.Errorf("%d) got %v want %v", i, got, tt.want)
+		}
+	}
+}
+
+func testCreatureAttacking(id uint64, target int) cardInstance {
+	c := instanceOf(falkenrathReaver)
+	c.id = id
+	c.attacking = target
+	c.tapped = true
+	return c
+}
+
+func testCreatureUntapped(id uint64) cardInstance {
+	c := instanceOf(falkenrathReaver)
+	c.id = id
+	c.attacking = -1
+	return c
+}
+
+func testOwnedBy(owner int, c cardInstance) cardInstance {
+	c.owner = owner
+	return c
+}
+
+func TestIsLegalBlock(t *testing.T) {
+	for i, tt := range []struct {
+		name     string
+		blockers []combatTarget
+		tapped   bool
+		want     bool
+	}{
+		{
+			name: "no blocks",
+			want: true,
+		},
+		{
+			name:     "single block",
+			blockers: []combatTarget{{id: 11, blocks: 1}},
+			want:     true,
+		},
+		{
+			name:     "tapped blocker",
+			blockers: []combatTarget{{id: 11, blocks: 1}},
+			tapped:   true,
+			want:     false,
+		},
+		{
+			name:     "blocker blocks twice",
+			blockers: []combatTarget{{id: 11, blocks: 1}, {id: 11, blocks: 2}},
+			want:     false,
+		},
+		{
+			name:     "target is not attacking",
+			blockers: []combatTarget{{id: 11, blocks: 3}},
+			want:     false,
+		},
+		{
+			name:     "blocker does not exist",
+			blockers: []combatTarget{{id: 12, blocks: 1}},
+			want:     false,
+		},
+	} {
+		blocker := testCreatureUntapped(11)
+		blocker.tapped = tt.tapped
+		g := &game{
+			players: []*player{
+				SELF: &player{idx: SELF, battlefield: battlefield{creatures: []cardInstance{
+					testCreatureAttacking(1, OPP), testCreatureAttacking(2, OPP), testCreatureUntapped(3),
+				}}},
+				OPP: &player{idx: OPP, battlefield: battlefield{creatures: []cardInstance{blocker}}},
+			},
+			numPlayers:   2,
+			activePlayer: SELF,
+			currentStep:  declareBlockersStep,
+		}
+		got := g.isLegalBlock(blockAction{action: action{controller: OPP}, blockers: tt.blockers})
+		if got != tt.want {
+			t.Errorf("%d: %s) got %v want %v", i, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlockedAttackerDealsNoDamage(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{idx: SELF, lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+				testCreatureAttacking(1, OPP), testCreatureAttacking(2, OPP),
+			}}},
+			OPP: &player{idx: OPP, lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+				testCreatureUntapped(11),
+			}}},
+		},
+		numPlayers:   2,
+		activePlayer: SELF,
+		currentStep:  declareBlockersStep,
+		numAttackers: 2,
+	}
+	if got := g.decisionPlayer(); got != OPP {
+		t.Fatalf("defending player should declare blockers, got %d", got)
+	}
+	g.resolveAction(blockAction{action: action{controller: OPP}, blockers: []combatTarget{{id: 11, blocks: 2}}})
+	if got := g.players[OPP].battlefield.creatures[0].blocking; got != 2 {
+		t.Errorf("blocker: got blocking %d want %d", got, 2)
+	}
+	if !g.players[SELF].battlefield.creatures[1].blocked {
+		t.Errorf("attacker should be blocked")
+	}
+	g.combatDamageStep()
+	if got := g.players[OPP].lifeTotal; got != 18 {
+		t.Errorf("life total: got %d want %d", got, 18)
+	}
+}
+
+func TestCombatDamageBetweenCreatures(t *testing.T) {
+	for i, tt := range []struct {
+		name         string
+		blockers     []combatTarget
+		wantLife     int
+		wantSelfDead int
+		wantOppDead  int
+	}{
+		{
+			name:     "unblocked",
+			wantLife: 16,
+		},
+		{
+			name:         "trade",
+			blockers:     []combatTarget{{id: 11, blocks: 1}},
+			wantLife:     18,
+			wantSelfDead: 1,
+			wantOppDead:  1,
+		},
+		{
+			name:         "double block",
+			blockers:     []combatTarget{{id: 11, blocks: 1}, {id: 12, blocks: 1}},
+			wantLife:     18,
+			wantSelfDead: 1,
+			wantOppDead:  1,
+		},
+	} {
+		g := &game{
+			players: []*player{
+				SELF: &player{idx: SELF, lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+					testCreatureAttacking(1, OPP), testCreatureAttacking(2, OPP),
+				}}},
+				OPP: &player{idx: OPP, lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+					testOwnedBy(OPP, testCreatureUntapped(11)), testOwnedBy(OPP, testCreatureUntapped(12)),
+				}}},
+			},
+			numPlayers:   2,
+			activePlayer: SELF,
+			currentStep:  declareBlockersStep,
+			numAttackers: 2,
+		}
+		g.resolveAction(blockAction{action: action{controller: OPP}, blockers: tt.blockers})
+		g.combatDamageStep()
+		g.checkStateBasedActions()
+		if got := g.players[OPP].lifeTotal; got != tt.wantLife {
+			t.Errorf("%d: %s) life total: got %d want %d", i, tt.name, got, tt.wantLife)
+		}
+		if got := len(g.players[SELF].graveyard); got != tt.wantSelfDead {
+			t.Errorf("%d: %s) attackers died: got %d want %d", i, tt.name, got, tt.wantSelfDead)
+		}
+		if got := len(g.players[OPP].graveyard); got != tt.wantOppDead {
+			t.Errorf("%d: %s) blockers died: got %d want %d", i, tt.name, got, tt.wantOppDead)
+		}
+		g.endOfCombatStep()
+		g.cleanupStep()
+		for _, p := range g.players {
+			for _, c := range p.battlefield.creatures {
+				if c.damage != 0 {
+					t.Errorf("%d: %s) damage should wear off in cleanup", i, tt.name)
+				}
+			}
+		}
+	}
+}
+
+func TestStateBasedActions(t *testing.T) {
+	legend := &creature{card: card{name: "Legend", legendary: true}, toughness: 1}
+	zeroToughness := &creature{card: card{name: "Zero"}}
+	for i, tt := range []struct {
+		name          string
+		self          *player
+		wantLosers    []int
+		wantCreatures int
+		wantGraveyard int
+	}{
+		{
+			name:       "life total",
+			self:       &player{lifeTotal: 0},
+			wantLosers: []int{SELF},
+		},
+		{
+			name:       "poison",
+			self:       &player{lifeTotal: 20, counters: map[counterType]int{poisonCounter: 10}},
+			wantLosers: []int{SELF},
+		},
+		{
+			name: "zero toughness",
+			self: &player{lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+				instanceOf(zeroToughness), testCreatureUntapped(1),
+			}}},
+			wantLosers:    []int{},
+			wantCreatures: 1,
+			wantGraveyard: 1,
+		},
+		{
+			name: "legend rule",
+			self: &player{lifeTotal: 20, strategy: goldfish{}, battlefield: battlefield{creatures: []cardInstance{
+				instanceOf(legend), instanceOf(legend),
+			}}},
+			wantLosers:    []int{},
+			wantCreatures: 1,
+			wantGraveyard: 1,
+		},
+		{
+			name: "aura attached to nothing",
+			self: &player{lifeTotal: 20, battlefield: battlefield{other: []cardInstance{
+				{id: 1, card: holyStrength, attachedTo: 42},
+			}}},
+			wantLosers:    []int{},
+			wantGraveyard: 1,
+		},
+		{
+			name: "aura attached to an illegal permanent",
+			self: &player{lifeTotal: 20, battlefield: battlefield{lands: []cardInstance{{id: 2, card: mountain}}, other: []cardInstance{
+				{id: 1, card: holyStrength, attachedTo: 2},
+			}}},
+			wantLosers:    []int{},
+			wantGraveyard: 1,
+		},
+		{
+			name: "equipment attached to nothing stays on the battlefield",
+			self: &player{lifeTotal: 20, battlefield: battlefield{other: []cardInstance{
+				{id: 1, card: bonesplitter, attachedTo: 42},
+			}}},
+			wantLosers: []int{},
+		},
+	} {
+		g := &game{
+			players:    []*player{SELF: tt.self, OPP: &player{lifeTotal: 20}},
+			numPlayers: 2,
+		}
+		got := g.checkStateBasedActions()
+		if !reflect.DeepEqual(got, tt.wantLosers) {
+			t.Errorf("%d: %s) losers: got %v want %v", i, tt.name, got, tt.wantLosers)
+		}
+		if got := len(tt.self.battlefield.creatures); got != tt.wantCreatures {
+			t.Errorf("%d: %s) creatures: got %d want %d", i, tt.name, got, tt.wantCreatures)
+		}
+		if got := len(tt.self.graveyard); got != tt.wantGraveyard {
+			t.Errorf("%d: %s) graveyard: got %d want %d", i, tt.name, got, tt.wantGraveyard)
+		}
+	}
+}
+
+func TestLegendRule(t *testing.T) {
+	legend := &creature{card: card{name: "Legend", legendary: true}, toughness: 1}
+	g := &game{
+		players: []*player{
+			SELF: &player{lifeTotal: 20, strategy: goldfish{}, battlefield: battlefield{creatures: []cardInstance{
+				{id: 1, card: legend, timestamp: 2}, {id: 2, card: legend, timestamp: 1},
+			}}},
+			OPP: &player{lifeTotal: 20},
+		},
+		numPlayers: 2,
+	}
+	g.checkStateBasedActions()
+	// the player chooses which legend to keep: the goldfish keeps the newest
+	creatures := g.players[SELF].battlefield.creatures
+	if len(creatures) != 1 || creatures[0].id != 1 {
+		t.Errorf("should keep the newest legend: got %v", creatures)
+	}
+}
+
+func TestCounterAnnihilation(t *testing.T) {
+	c := testCreatureUntapped(1)
+	c.counters = map[counterType]int{plusOneCounter: 2, minusOneCounter: 3}
+	// 704.5q applies to any permanent, not just creatures
+	tome := cardInstance{id: 2, card: jayemdaeTome, counters: map[counterType]int{plusOneCounter: 1, minusOneCounter: 1}}
+	g := &game{
+		players:    []*player{SELF: &player{lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{c}, other: []cardInstance{tome}}}, OPP: &player{lifeTotal: 20}},
+		numPlayers: 2,
+	}
+	g.checkStateBasedActions()
+	got := g.players[SELF].battlefield.creatures[0].counters
+	want := map[counterType]int{minusOneCounter: 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got := g.players[SELF].battlefield.other[0].counters; len(got) != 0 {
+		t.Errorf("noncreature permanent: got %v want no counters", got)
+	}
+}
+
+func TestResolveIllegalTargets(t *testing.T) {
+	for i, tt := range []struct {
+		name     string
+		stack    []stackObject
+		lost     bool
+		wantLife int
+	}{
+		{
+			name: "legal target",
+			stack: []stackObject{cardAction{
+				card:    lavaSpike,
+				action:  action{controller: SELF},
+				targets: []effectTarget{{index: target(OPP), ttype: targetPlayer}},
+			}},
+			wantLife: 17,
+		},
+		{
+			name: "target player left the game",
+			stack: []stackObject{cardAction{
+				card:    lavaSpike,
+				action:  action{controller: SELF},
+				targets: []effectTarget{{index: target(OPP), ttype: targetPlayer}},
+			}},
+			lost:     true,
+			wantLife: 20,
+		},
+		{
+			name: "targeted spell is gone",
+			stack: []stackObject{cardAction{
+				id:      1,
+				card:    counterspell,
+				action:  action{controller: SELF},
+				targets: []effectTarget{{id: 2, ttype: targetSpell}},
+			}},
+			wantLife: 20,
+		},
+	} {
+		g := &game{
+			players: []*player{
+				SELF: &player{idx: SELF, lifeTotal: 20},
+				OPP:  &player{idx: OPP, lifeTotal: 20, lost: tt.lost},
+			},
+			numPlayers: 2,
+			stack:      tt.stack,
+		}
+		card := tt.stack[0].(cardAction).card
+		g.resolve()
+		if got := g.players[OPP].lifeTotal; got != tt.wantLife {
+			t.Errorf("%d: %s) life total: got %d want %d", i, tt.name, got, tt.wantLife)
+		}
+		if got := g.players[SELF].graveyard; !reflect.DeepEqual(got, orderedCards{card}) {
+			t.Errorf("%d: %s) graveyard: got %v want %v", i, tt.name, got, orderedCards{card})
+		}
+	}
+}
+
+func TestActivateAbility(t *testing.T) {
+	for i, tt := range []struct {
+		name          string
+		source        Card
+		wantCreatures int
+		wantGraveyard int
+	}{
+		{
+			name:          "tap ability",
+			source:        prodigalPyromancer,
+			wantCreatures: 1,
+		},
+		{
+			name:          "sacrifice ability",
+			source:        moggFanatic,
+			wantGraveyard: 1,
+		},
+	} {
+		c := instanceOf(tt.source)
+		c.id = 1
+		g := &game{
+			players: []*player{
+				SELF: &player{idx: SELF, lifeTotal: 20, strategy: goldfish{}, battlefield: battlefield{creatures: []cardInstance{c}}},
+				OPP:  &player{idx: OPP, lifeTotal: 20, strategy: goldfish{}},
+			},
+			numPlayers:     2,
+			activePlayer:   SELF,
+			priorityPlayer: SELF,
+			currentStep:    precombatMainPhase,
+		}
+		if !g.players[SELF].canActivate(g, c, 0) {
+			t.Fatalf("%d: %s) should be able to activate", i, tt.name)
+		}
+		g.resolveAction(activateAction{action: action{controller: SELF}, id: 1, index: 0, targets: []effectTarget{{index: target(OPP), ttype: anyTarget}}})
+		self := g.players[SELF]
+		if got := len(self.battlefield.creatures); got != tt.wantCreatures {
+			t.Errorf("%d: %s) creatures: got %d want %d", i, tt.name, got, tt.wantCreatures)
+		}
+		if got := len(self.graveyard); got != tt.wantGraveyard {
+			t.Errorf("%d: %s) graveyard: got %d want %d", i, tt.name, got, tt.wantGraveyard)
+		}
+		if tt.wantCreatures > 0 && !self.battlefield.creatures[0].tapped {
+			t.Errorf("%d: %s) source should be tapped", i, tt.name)
+		}
+		if len(g.stack) != 1 {
+			t.Fatalf("%d: %s) ability should be on the stack", i, tt.name)
+		}
+		g.resolve()
+		if got := g.players[OPP].lifeTotal; got != 19 {
+			t.Errorf("%d: %s) life total: got %d want %d", i, tt.name, got, 19)
+		}
+	}
+}
+
+func TestCombatKeywords(t *testing.T) {
+	for i, tt := range []struct {
+		name         string
+		attacker     Card
+		blockers     []Card
+		wantIllegal  bool
+		wantOppLife  int
+		wantSelfLife int
+		wantSelfDead int
+		wantOppDead  int
+	}{
+		{
+			name:        "flying can't be blocked by ground creatures",
+			attacker:    serraAngel,
+			blockers:    []Card{falkenrathReaver},
+			wantIllegal: true,
+		},
+		{
+			name:         "reach blocks flying",
+			attacker:     serraAngel,
+			blockers:     []Card{giantSpider},
+			wantOppLife:  20,
+			wantSelfLife: 20,
+			wantOppDead:  1,
+		},
+		{
+			name:        "menace can't be blocked by one creature",
+			attacker:    boggartBrute,
+			blockers:    []Card{falkenrathReaver},
+			wantIllegal: true,
+		},
+		{
+			name:         "menace blocked by two creatures",
+			attacker:     boggartBrute,
+			blockers:     []Card{falkenrathReaver, falkenrathReaver},
+			wantOppLife:  20,
+			wantSelfLife: 20,
+			wantSelfDead: 1,
+			wantOppDead:  1,
+		},
+		{
+			name:         "first strike kills blocker before it deals damage",
+			attacker:     youthfulKnight,
+			blockers:     []Card{ragingGoblin},
+			wantOppLife:  20,
+			wantSelfLife: 20,
+			wantOppDead:  1,
+		},
+		{
+			name:         "double strike unblocked",
+			attacker:     borosSwiftblade,
+			wantOppLife:  18,
+			wantSelfLife: 20,
+		},
+		{
+			name:         "trample assigns excess damage to the player",
+			attacker:     colossalDreadmaw,
+			blockers:     []Card{falkenrathReaver},
+			wantOppLife:  16,
+			wantSelfLife: 20,
+			wantOppDead:  1,
+		},
+		{
+			name:         "deathtouch and lifelink",
+			attacker:     vampireNighthawk,
+			blockers:     []Card{giantSpider},
+			wantOppLife:  20,
+			wantSelfLife: 22,
+			wantOppDead:  1,
+		},
+	} {
+		oppCreatures := []cardInstance{}
+		blocks := []combatTarget{}
+		for n, c := range tt.blockers {
+			id := uint64(11 + n)
+			oppCreatures = append(oppCreatures, cardInstance{id: id, card: c, owner: OPP, attacking: -1})
+			blocks = append(blocks, combatTarget{id: id, blocks: 1})
+		}
+		g := &game{
+			players: []*player{
+				SELF: &player{idx: SELF, lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+					{id: 1, card: tt.attacker, attacking: -1},
+				}}},
+				OPP: &player{idx: OPP, lifeTotal: 20, battlefield: battlefield{creatures: oppCreatures}},
+			},
+			numPlayers:   2,
+			activePlayer: SELF,
+			currentStep:  declareAttackersStep,
+		}
+		g.resolveAction(attackAction{action: action{controller: SELF}, attackers: []combatTarget{{id: 1, target: OPP}}})
+		g.currentStep = declareBlockersStep
+		block := blockAction{action: action{controller: OPP}, blockers: blocks}
+		if got := !g.isLegalBlock(block); got != tt.wantIllegal {
+			t.Errorf("%d: %s) illegal block: got %v want %v", i, tt.name, got, tt.wantIllegal)
+		}
+		if tt.wantIllegal {
+			continue
+		}
+		g.resolveAction(block)
+		if g.hasFirstStrikeStep() {
+			g.combatDamageFirstStrikeStep()
+			g.checkStateBasedActions()
+		}
+		g.combatDamageStep()
+		g.checkStateBasedActions()
+		if got := g.players[OPP].lifeTotal; got != tt.wantOppLife {
+			t.Errorf("%d: %s) opponent life total: got %d want %d", i, tt.name, got, tt.wantOppLife)
+		}
+		if got := g.players[SELF].lifeTotal; got != tt.wantSelfLife {
+			t.Errorf("%d: %s) own life total: got %d want %d", i, tt.name, got, tt.wantSelfLife)
+		}
+		if got := len(g.players[SELF].graveyard); got != tt.wantSelfDead {
+			t.Errorf("%d: %s) attackers died: got %d want %d", i, tt.name, got, tt.wantSelfDead)
+		}
+		if got := len(g.players[OPP].graveyard); got != tt.wantOppDead {
+			t.Errorf("%d: %s) blockers died: got %d want %d", i, tt.name, got, tt.wantOppDead)
+		}
+	}
+}
+
+func TestVigilanceAndHaste(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{idx: SELF, battlefield: battlefield{creatures: []cardInstance{
+				{id: 1, card: serraAngel, attacking: -1},
+				{id: 2, card: ragingGoblin, attacking: -1, summoningSickness: true},
+				{id: 3, card: falkenrathReaver, attacking: -1, summoningSickness: true},
+			}}},
+			OPP: &player{idx: OPP},
+		},
+		numPlayers:   2,
+		activePlayer: SELF,
+		currentStep:  declareAttackersStep,
+	}
+	if got, want := g.players[SELF].creaturesThatCanAttack(g), []uint64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("creatures that can attack: got %v want %v", got, want)
+	}
+	g.resolveAction(attackWithAll(g, g.players[SELF], SELF))
+	creatures := g.players[SELF].battlefield.creatures
+	if creatures[0].tapped {
+		t.Errorf("attacking with vigilance should not tap")
+	}
+	if !creatures[1].tapped {
+		t.Errorf("attacking without vigilance should tap")
+	}
+}
+
+func TestAuraAndEquipment(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{idx: SELF, lifeTotal: 20, strategy: goldfish{}, battlefield: battlefield{
+				lands:     testManaAvailable(2).lands,
+				creatures: []cardInstance{testCreatureUntapped(1)},
+				other:     []cardInstance{{id: 2, card: bonesplitter}},
+			}},
+			OPP: &player{idx: OPP, lifeTotal: 20, strategy: goldfish{}, battlefield: battlefield{
+				creatures: []cardInstance{testCreatureUntapped(11)},
+			}},
+		},
+		numPlayers:     2,
+		activePlayer:   SELF,
+		priorityPlayer: SELF,
+		currentStep:    precombatMainPhase,
+	}
+	self := g.players[SELF]
+	equipped := self.battlefield.creatures[0]
+	if !self.canActivate(g, self.battlefield.other[0], 0) {
+		t.Fatalf("should be able to equip at sorcery speed")
+	}
+	g.resolveAction(activateAction{action: action{controller: SELF}, id: 2, index: 0, targets: []effectTarget{{id: 1, ttype: targetCreatureYouControl}}})
+	if self.canActivate(g, self.battlefield.other[0], 0) {
+		t.Errorf("should not be able to equip with a nonempty stack")
+	}
+	g.resolve()
+	if got := self.battlefield.other[0].attachedTo; got != 1 {
+		t.Fatalf("equipment attached to: got %d want %d", got, 1)
+	}
+	if got := g.power(equipped); got != 4 {
+		t.Errorf("equipped creature power: got %d want %d", got, 4)
+	}
+
+	g.stack = []stackObject{cardAction{card: pacifism, action: action{controller: SELF}, targets: []effectTarget{{id: 11, ttype: targetCreature}}}}
+	g.resolve()
+	opp := g.players[OPP]
+	if got := len(opp.creaturesThatCanBlock(g)); got != 0 {
+		t.Errorf("enchanted creature should not be able to block")
+	}
+	if got := len(self.battlefield.other); got != 2 {
+		t.Fatalf("aura should be on the battlefield")
+	}
+	aura := self.battlefield.other[1]
+	if aura.attachedTo != 11 {
+		t.Errorf("aura attached to: got %d want %d", aura.attachedTo, 11)
+	}
+
+	destroy{}.apply(g, []effectTarget{{id: 11, ttype: targetCreature}})
+	destroy{}.apply(g, []effectTarget{{id: 1, ttype: targetCreature}})
+	g.checkStateBasedActions()
+	if got := len(self.battlefield.other); got != 1 {
+		t.Fatalf("aura should fall off, equipment should stay: got %d permanents", got)
+	}
+	if self.battlefield.other[0].attachedTo != 0 {
+		t.Errorf("equipment should become unattached")
+	}
+}
+
+func TestPlaneswalker(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{idx: SELF, lifeTotal: 20, strategy: goldfish{}, battlefield: battlefield{
+				creatures: []cardInstance{{id: 1, card: falkenrathReaver, attacking: -1}},
+			}},
+			OPP: &player{idx: OPP, lifeTotal: 20, strategy: goldfish{}, library: orderedCards{island, island}},
+		},
+		numPlayers:     2,
+		activePlayer:   OPP,
+		priorityPlayer: OPP,
+		currentStep:    precombatMainPhase,
+	}
+	opp := g.players[OPP]
+	g.stack = []stackObject{cardAction{card: jaceBeleren, action: action{controller: OPP}}}
+	g.resolve()
+	jace := opp.battlefield.other[0]
+	if got := jace.counters[loyaltyCounter]; got != 3 {
+		t.Fatalf("loyalty: got %d want %d", got, 3)
+	}
+	if opp.canActivate(g, jace, 2) {
+		t.Errorf("should not be able to remove more loyalty counters than it has")
+	}
+	g.resolveAction(activateAction{action: action{controller: OPP}, id: jace.id, index: 1, targets: []effectTarget{{index: target(OPP), ttype: targetPlayer}}})
+	g.resolve()
+	jace = opp.battlefield.other[0]
+	if got := jace.counters[loyaltyCounter]; got != 2 {
+		t.Errorf("loyalty after activation: got %d want %d", got, 2)
+	}
+	if got := len(opp.library); got != 1 {
+		t.Errorf("library: got %d want %d", got, 1)
+	}
+	if opp.canActivate(g, jace, 0) {
+		t.Errorf("should only activate one loyalty ability per turn")
+	}
+	g.cleanupStep()
+	if !opp.canActivate(g, opp.battlefield.other[0], 0) {
+		t.Errorf("should be able to activate a loyalty ability next turn")
+	}
+
+	g.activePlayer, g.priorityPlayer = SELF, SELF
+	g.currentStep = declareAttackersStep
+	g.resolveAction(attackAction{action: action{controller: SELF}, attackers: []combatTarget{{id: 1, target: OPP, planeswalker: jace.id}}})
+	g.combatDamageStep()
+	if got := opp.lifeTotal; got != 20 {
+		t.Errorf("life total: got %d want %d", got, 20)
+	}
+	g.checkStateBasedActions()
+	if got := len(opp.battlefield.other); got != 0 {
+		t.Errorf("planeswalker with 0 loyalty should be put into the graveyard")
+	}
+	if got := len(opp.graveyard); got != 1 {
+		t.Errorf("graveyard: got %d want %d", got, 1)
+	}
+}
+
+func TestInfect(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{idx: SELF, lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+				{id: 1, card: glistenerElf, attacking: OPP}, {id: 2, card: glistenerElf, attacking: OPP},
+			}}},
+			OPP: &player{idx: OPP, lifeTotal: 20, counters: map[counterType]int{poisonCounter: 9}, battlefield: battlefield{creatures: []cardInstance{
+				testCreatureUntapped(11),
+			}}},
+		},
+		numPlayers:   2,
+		activePlayer: SELF,
+		currentStep:  declareBlockersStep,
+		numAttackers: 2,
+	}
+	g.resolveAction(blockAction{action: action{controller: OPP}, blockers: []combatTarget{{id: 11, blocks: 2}}})
+	g.combatDamageStep()
+	opp := g.players[OPP]
+	if got := opp.lifeTotal; got != 20 {
+		t.Errorf("life total: got %d want %d", got, 20)
+	}
+	if got := opp.battlefield.creatures[0]; got.damage != 0 || g.toughness(got) != 1 {
+		t.Errorf("blocker should get -1/-1 counters instead of damage: got %+v", got)
+	}
+	// 510.2 combat damage is simultaneous: the blocker deals damage with its power before the counters
+	if got := g.players[SELF].battlefield.creatures[1].damage; got != 2 {
+		t.Errorf("blocker should deal damage equal to its power as damage was assigned: got %d want %d", got, 2)
+	}
+	if got := g.checkStateBasedActions(); !reflect.DeepEqual(got, []int{OPP}) {
+		t.Errorf("losers: got %v want %v", got, []int{OPP})
+	}
+}
+
+func TestCleanupStep(t *testing.T) {
+	for i, tt := range []struct {
+		name          string
+		self          *player
+		effects       []activeEffect
+		wantHand      int
+		wantGraveyard orderedCards
+		wantPriority  bool
+	}{
+		{
+			name:     "nothing happens",
+			self:     &player{hand: unorderedCards{mountain: 3}},
+			wantHand: 3,
+		},
+		{
+			name:          "discard to maximum hand size",
+			self:          &player{hand: unorderedCards{mountain: 5, lavaSpike: 2, divination: 2}},
+			wantHand:      7,
+			wantGraveyard: orderedCards{divination, divination},
+		},
+		{
+			name: "creature dies when an effect ends",
+			self: &player{battlefield: battlefield{creatures: []cardInstance{
+				{id: 1, card: goblinArsonist, counters: map[counterType]int{minusOneCounter: 3}},
+			}}},
+			effects: []activeEffect{
+				{effect: continuousEffect{layer: modifyPTLayer, power: 3, toughness: 3}, ids: []uint64{1}, duration: untilEndOfTurn, timestamp: 1},
+			},
+			wantGraveyard: orderedCards{goblinArsonist},
+			wantPriority:  true,
+		},
+	} {
+		tt.self.idx, tt.self.lifeTotal, tt.self.strategy = SELF, 20, simpleStrategy{}
+		g := &game{
+			players:           []*player{SELF: tt.self, OPP: &player{idx: OPP, lifeTotal: 20, strategy: simpleStrategy{}}},
+			numPlayers:        2,
+			activePlayer:      SELF,
+			currentStep:       cleanupStep,
+			continuousEffects: tt.effects,
+		}
+		g.nextDecisionPoint()
+		if got := tt.self.hand.size(); got != tt.wantHand {
+			t.Errorf("%d: %s) hand size: got %d want %d", i, tt.name, got, tt.wantHand)
+		}
+		if !reflect.DeepEqual(tt.self.graveyard, tt.wantGraveyard) {
+			t.Errorf("%d: %s) graveyard: got %v want %v", i, tt.name, tt.self.graveyard, tt.wantGraveyard)
+		}
+		if got := g.currentStep == cleanupStep; got != tt.wantPriority {
+			t.Errorf("%d: %s) players receive priority in cleanup: got %v want %v", i, tt.name, got, tt.wantPriority)
+		}
+	}
+}
+
+// mulligans a fixed number of times, then keeps
+type mulliganTimes struct {
+	goldfish
+	n int
+}
+
+func (m mulliganTimes) Mulligan(hand unorderedCards, mulligansTaken int) bool {
+	return mulligansTaken < m.n
+}
+
+func TestMulligan(t *testing.T) {
+	for i, tt := range []struct {
+		name       string
+		strategy   Strategy
+		library    orderedCards
+		wantHand   int
+		wantBottom Card
+	}{
+		{
+			name:     "keep seven",
+			strategy: goldfish{},
+			library:  orderedCards{mountain, mountain, mountain, mountain, lavaSpike, lavaSpike, lavaSpike, lavaSpike, lavaSpike, lavaSpike},
+			wantHand: 7,
+		},
+		{
+			name:       "mulligan to six bottoms the most expensive spell",
+			strategy:   mulliganTimes{n: 1},
+			library:    orderedCards{mountain, mountain, mountain, lavaSpike, lavaSpike, lavaSpike, divination},
+			wantHand:   6,
+			wantBottom: divination,
+		},
+		{
+			name:       "mulligan to five bottoms lands from a flooded hand",
+			strategy:   mulliganTimes{n: 2},
+			library:    orderedCards{mountain, mountain, mountain, mountain, mountain, mountain, lavaSpike},
+			wantHand:   5,
+			wantBottom: mountain,
+		},
+	} {
+		p := &player{library: tt.library, hand: unorderedCards{}, strategy: tt.strategy}
+		g := &game{players: []*player{p}, numPlayers: 1}
+		g.drawCards(p.idx, 7)
+		g.mulligan(p)
+		if got := p.hand.size(); got != tt.wantHand {
+			t.Errorf("%d: %s) hand size: got %d want %d", i, tt.name, got, tt.wantHand)
+		}
+		if got := p.hand.size() + len(p.library); got != len(tt.library) {
+			t.Errorf("%d: %s) cards lost: got %d want %d", i, tt.name, got, len(tt.library))
+		}
+		if tt.wantBottom == nil {
+			continue
+		}
+		if got := p.library[len(p.library)-1]; got != tt.wantBottom {
+			t.Errorf("%d: %s) bottom card: got %s want %s", i, tt.name, got.getName(), tt.wantBottom.getName())
+		}
+	}
+}
+
+func TestMulliganOnLandCount(t *testing.T) {
+	for i, tt := range []struct {
+		hand           unorderedCards
+		mulligansTaken int
+		want           bool
+	}{
+		{
+			hand: unorderedCards{mountain: 1, lavaSpike: 6},
+			want: true,
+		},
+		{
+			hand: unorderedCards{mountain: 3, lavaSpike: 4},
+			want: false,
+		},
+		{
+			hand: unorderedCards{mountain: 6, lavaSpike: 1},
+			want: true,
+		},
+		{
+			hand:           unorderedCards{mountain: 7},
+			mulligansTaken: 2,
+			want:           false,
+		},
+	} {
+		if got := mulliganOnLandCount(tt.hand, tt.mulligansTaken); got != tt.want {
+			t.Errorf("%d) got %t want %t", i, got, tt.want)
+		}
+	}
+}
+
+func TestStartingPlayerSkipsFirstDraw(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{lifeTotal: 20, library: orderedCards{mountain, mountain}},
+			OPP:  &player{lifeTotal: 20, library: orderedCards{island, island}},
+		},
+		numPlayers:     2,
+		turn:           1,
+		startingPlayer: SELF,
+		activePlayer:   SELF,
+	}
+	g.drawStep()
+	if got := g.getPlayer(SELF).hand.size(); got != 0 {
+		t.Errorf("starting player should skip their first draw: got %d cards", got)
+	}
+	g.activePlayer = OPP
+	g.drawStep()
+	if got := g.getPlayer(OPP).hand.size(); got != 1 {
+		t.Errorf("other player should draw on their first turn: got %d cards", got)
+	}
+}
+
+func TestTurnOrder(t *testing.T) {
+	g := &game{
+		players: []*player{
+			{lifeTotal: 20},
+			{lifeTotal: 20, lost: true},
+			{lifeTotal: 20},
+			{lifeTotal: 20},
+		},
+		numPlayers:     4,
+		turn:           1,
+		activePlayer:   2,
+		startingPlayer: 0,
+	}
+	if got, want := g.getOpponents(2), []int{3, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("opponents: got %v want %v", got, want)
+	}
+	if got, want := g.apnapOrder(), []int{2, 3, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("apnap order: got %v want %v", got, want)
+	}
+	g.nextTurn()
+	if g.activePlayer != 3 || g.priorityPlayer != 3 || g.turn != 1 {
+		t.Errorf("got active player %d priority %d turn %d, want 3 3 1", g.activePlayer, g.priorityPlayer, g.turn)
+	}
+	g.nextTurn()
+	if g.activePlayer != 0 || g.turn != 2 {
+		t.Errorf("got active player %d turn %d, want 0 2", g.activePlayer, g.turn)
+	}
+	// the eliminated player is skipped
+	g.nextTurn()
+	if g.activePlayer != 2 || g.turn != 2 {
+		t.Errorf("got active player %d turn %d, want 2 2", g.activePlayer, g.turn)
+	}
+}
+
+func TestEliminate(t *testing.T) {
+	g := &game{
+		players: []*player{
+			{lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{{id: 1, card: falkenrathReaver, attacking: 1}, {id: 2, card: falkenrathReaver, attacking: 2}}}},
+			{lifeTotal: 0, battlefield: battlefield{creatures: []cardInstance{{id: 3, card: falkenrathReaver, owner: 1}, {id: 4, card: falkenrathReaver, owner: 2}}}},
+			{lifeTotal: 20},
+		},
+		numPlayers:     3,
+		activePlayer:   0,
+		priorityPlayer: 1,
+		stack:          []stackObject{cardAction{card: shock, action: action{controller: 1}}, cardAction{card: shock, action: action{controller: 2}}},
+	}
+	for _, i := range g.checkStateBasedActions() {
+		g.eliminate(i)
+	}
+	if !g.getPlayer(1).lost || g.isOver() {
+		t.Fatalf("only player 1 should have left the game")
+	}
+	if len(g.getPlayer(1).battlefield.creatures) != 0 {
+		t.Errorf("objects of the eliminated player should have left the battlefield")
+	}
+	if !reflect.DeepEqual(g.getPlayer(2).exile, orderedCards{falkenrathReaver}) {
+		t.Errorf("objects controlled but not owned by the eliminated player should be exiled: got %v", g.getPlayer(2).exile)
+	}
+	if len(g.stack) != 1 || g.stack[0].getController() != 2 {
+		t.Errorf("spells controlled by the eliminated player should cease to exist: got %v", g.stack)
+	}
+	creatures := g.getPlayer(0).battlefield.creatures
+	if creatures[0].attacking != -1 || creatures[1].attacking != 2 {
+		t.Errorf("creatures attacking the eliminated player should be removed from combat")
+	}
+	if g.priorityPlayer != 2 {
+		t.Errorf("priority should pass to the next player: got %d", g.priorityPlayer)
+	}
+}
+
+func TestMultiplayerCombat(t *testing.T) {
+	g := &game{
+		players: []*player{
+			{lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{{id: 1, card: falkenrathReaver, attacking: -1}}}},
+			{lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{{id: 2, card: falkenrathReaver, attacking: -1}}}},
+			{lifeTotal: 10, battlefield: battlefield{creatures: []cardInstance{{id: 3, card: falkenrathReaver, attacking: -1}}}},
+		},
+		numPlayers:  3,
+		currentStep: declareAttackersStep,
+	}
+	// simple strategy attacks the opponent with the lowest life total
+	g.resolveAction(simpleStrategy{}.Attacks(g.getPlayer(0), g))
+	if got := g.getPlayer(0).battlefield.creatures[0].attacking; got != 2 {
+		t.Fatalf("should attack the weakest opponent: got %d", got)
+	}
+	g.nextStep()
+	// each defending player declares blockers in turn order
+	for _, want := range []int{1, 2} {
+		if !g.declaringBlockers() || g.decisionPlayer() != want {
+			t.Fatalf("player %d should declare blockers, got %d", want, g.decisionPlayer())
+		}
+		g.resolveAction(blockAction{action: action{controller: want}})
+	}
+	if g.declaringBlockers() {
+		t.Errorf("all defending players have declared blockers")
+	}
+}
+
+func TestActivePlayerLeavesTheGame(t *testing.T) {
+	g := &game{
+		players: []*player{
+			{idx: 0, lifeTotal: 0, strategy: simpleStrategy{}, battlefield: battlefield{creatures: []cardInstance{{id: 1, card: falkenrathReaver, attacking: -1}}}},
+			{idx: 1, lifeTotal: 20, strategy: simpleStrategy{}, hand: unorderedCards{shock: 1}, battlefield: battlefield{
+				lands:     []cardInstance{{id: 2, card: mountain, owner: 1}},
+				creatures: []cardInstance{{id: 3, card: falkenrathReaver, owner: 1, attacking: -1}},
+			}},
+			{idx: 2, lifeTotal: 20, strategy: simpleStrategy{}},
+		},
+		numPlayers:     3,
+		activePlayer:   0,
+		priorityPlayer: 0,
+		currentStep:    beginningOfCombatStep,
+	}
+	for _, i := range g.checkStateBasedActions() {
+		g.eliminate(i)
+	}
+	// 800.4a the turn continues without an active player
+	if g.activePlayer != 0 || g.priorityPlayer != 1 {
+		t.Fatalf("turn should continue, with priority for the next player: got active %d priority %d", g.activePlayer, g.priorityPlayer)
+	}
+	g.resolveAction(passAction{action{controller: 1}})
+	g.resolveAction(passAction{action{controller: 2}})
+	// nobody declares attackers in place of the player who left, so combat is skipped
+	if g.currentStep != postcombatMainPhase {
+		t.Fatalf("combat should be skipped: got %s", stepName(g.currentStep))
+	}
+	if c := g.getPlayer(1).battlefield.creatures[0]; c.attacking != -1 || c.tapped {
+		t.Errorf("creatures of other players should not attack")
+	}
+	if g.declaringAttackers() || g.declaringBlockers() {
+		t.Errorf("no attackers or blockers are declared without an active player")
+	}
+	// effects for each player skip the player who left
+	damage{amount: 1}.apply(g, []effectTarget{{ttype: eachPlayer}})
+	if g.getPlayer(0).lifeTotal != 0 || g.getPlayer(1).lifeTotal != 19 || g.getPlayer(2).lifeTotal != 19 {
+		t.Errorf("damage to each player: got %d %d %d", g.getPlayer(0).lifeTotal, g.getPlayer(1).lifeTotal, g.getPlayer(2).lifeTotal)
+	}
+}
@@ -2,4 +2,4 @@ module github.com/deosjr/LearnMTG
 
 go 1.19
 
-require github.com/MagicTheGathering/mtg-sdk-go v0.0.0-20190109105601-3aaea97721aa // indirect
+require github.com/MagicTheGathering/mtg-sdk-go v0.0.0-20190109105601-3aaea97721aa
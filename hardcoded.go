@@ -14,6 +14,7 @@ var (
 				},
 			},
 		},
+		basic: true,
 	}
 
 	island = &land{
@@ -29,6 +30,7 @@ var (
 				},
 			},
 		},
+		basic: true,
 	}
 
 	lavaSpike = &sorcery{
@@ -70,6 +72,58 @@ var (
 		},
 	}
 
+	shock = &instant{
+		card: card{
+			name:     "Shock",
+			manaCost: mana{r: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{anyTarget},
+				effect:  damage{2},
+			},
+		},
+	}
+
+	counterspell = &instant{
+		card: card{
+			name:     "Counterspell",
+			manaCost: mana{u: 2},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{targetSpell},
+				effect:  counter{},
+			},
+		},
+	}
+
+	murder = &instant{
+		card: card{
+			name:     "Murder",
+			manaCost: mana{c: 1, b: 2},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{targetCreature},
+				effect:  destroy{},
+			},
+		},
+	}
+
+	unsummon = &instant{
+		card: card{
+			name:     "Unsummon",
+			manaCost: mana{u: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{targetCreature},
+				effect:  bounce{},
+			},
+		},
+	}
+
 	falkenrathReaver = &creature{
 		card: card{
 			name:     "Falkenrath Reaver",
@@ -79,11 +133,588 @@ var (
 		toughness: 2,
 	}
 
+	prodigalPyromancer = &creature{
+		card: card{
+			name:     "Prodigal Pyromancer",
+			manaCost: mana{c: 2, r: 1},
+			activatedAbilities: []ActivatedAbility{
+				{
+					cost: cost{tap: true},
+					ability: ability{
+						targets: []targetType{anyTarget},
+						effect:  damage{1},
+					},
+				},
+			},
+		},
+		power:     1,
+		toughness: 1,
+	}
+
+	moggFanatic = &creature{
+		card: card{
+			name:     "Mogg Fanatic",
+			manaCost: mana{r: 1},
+			activatedAbilities: []ActivatedAbility{
+				{
+					cost: cost{sacrifice: true},
+					ability: ability{
+						targets: []targetType{anyTarget},
+						effect:  damage{1},
+					},
+				},
+			},
+		},
+		power:     1,
+		toughness: 1,
+	}
+
+	flametongueKavu = &creature{
+		card: card{
+			name:     "Flametongue Kavu",
+			manaCost: mana{c: 3, r: 1},
+			triggeredAbilities: []TriggeredAbility{
+				{
+					trigger: trigger{event: entersTheBattlefield, self: true},
+					ability: ability{
+						targets: []targetType{targetCreature},
+						effect:  damage{4},
+					},
+				},
+			},
+		},
+		power:     4,
+		toughness: 2,
+	}
+
+	guttersnipe = &creature{
+		card: card{
+			name:     "Guttersnipe",
+			manaCost: mana{c: 2, r: 1},
+			triggeredAbilities: []TriggeredAbility{
+				{
+					trigger: trigger{event: spellCast, you: true, filter: isInstantOrSorcery},
+					ability: ability{
+						targets: []targetType{eachOpponent},
+						effect:  damage{2},
+					},
+				},
+			},
+		},
+		power:     2,
+		toughness: 2,
+	}
+
+	goblinArsonist = &creature{
+		card: card{
+			name:     "Goblin Arsonist",
+			manaCost: mana{r: 1},
+			triggeredAbilities: []TriggeredAbility{
+				{
+					trigger: trigger{event: dies, self: true},
+					ability: ability{
+						targets: []targetType{anyTarget},
+						effect:  damage{1},
+					},
+				},
+			},
+		},
+		power:     1,
+		toughness: 1,
+	}
+
+	bruteForce = &instant{
+		card: card{
+			name:     "Brute Force",
+			manaCost: mana{r: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{targetCreature},
+				effect:  pump{power: 3, toughness: 3},
+			},
+		},
+	}
+
+	benalishMarshal = &creature{
+		card: card{
+			name:     "Benalish Marshal",
+			manaCost: mana{w: 3},
+			staticAbilities: []StaticAbility{
+				{effect: &continuousEffect{layer: modifyPTLayer, scope: scopeOtherCreaturesYouControl, power: 1, toughness: 1}},
+			},
+		},
+		power:     3,
+		toughness: 3,
+	}
+
+	ogreTaskmaster = &creature{
+		card: card{
+			name:     "Ogre Taskmaster",
+			manaCost: mana{c: 3, r: 1},
+			staticAbilities: []StaticAbility{
+				{effect: &continuousEffect{layer: abilityLayer, scope: scopeSelf, cantBlock: true}},
+			},
+		},
+		power:     4,
+		toughness: 3,
+	}
+
+	baral = &creature{
+		card: card{
+			name:      "Baral, Chief of Compliance",
+			manaCost:  mana{c: 1, u: 1},
+			legendary: true,
+			staticAbilities: []StaticAbility{
+				{costReduction: 1, costFilter: isInstantOrSorcery},
+			},
+		},
+		power:     1,
+		toughness: 3,
+	}
+
+	serraAngel = &creature{
+		card: card{
+			name:     "Serra Angel",
+			manaCost: mana{c: 3, w: 2},
+		},
+		power:     4,
+		toughness: 4,
+		keywords:  flying | vigilance,
+	}
+
+	ragingGoblin = &creature{
+		card: card{
+			name:     "Raging Goblin",
+			manaCost: mana{r: 1},
+		},
+		power:     1,
+		toughness: 1,
+		keywords:  haste,
+	}
+
+	vampireNighthawk = &creature{
+		card: card{
+			name:     "Vampire Nighthawk",
+			manaCost: mana{c: 1, b: 2},
+		},
+		power:     2,
+		toughness: 3,
+		keywords:  flying | deathtouch | lifelink,
+	}
+
+	borosSwiftblade = &creature{
+		card: card{
+			name:     "Boros Swiftblade",
+			manaCost: mana{r: 1, w: 1},
+		},
+		power:     1,
+		toughness: 2,
+		keywords:  doubleStrike,
+	}
+
+	giantSpider = &creature{
+		card: card{
+			name:     "Giant Spider",
+			manaCost: mana{c: 3, g: 1},
+		},
+		power:     2,
+		toughness: 4,
+		keywords:  reach,
+	}
+
+	colossalDreadmaw = &creature{
+		card: card{
+			name:     "Colossal Dreadmaw",
+			manaCost: mana{c: 4, g: 2},
+		},
+		power:     6,
+		toughness: 6,
+		keywords:  trample,
+	}
+
+	boggartBrute = &creature{
+		card: card{
+			name:     "Boggart Brute",
+			manaCost: mana{c: 2, r: 1},
+		},
+		power:     3,
+		toughness: 2,
+		keywords:  menace,
+	}
+
+	youthfulKnight = &creature{
+		card: card{
+			name:     "Youthful Knight",
+			manaCost: mana{c: 1, w: 1},
+		},
+		power:     2,
+		toughness: 1,
+		keywords:  firstStrike,
+	}
+
+	fog = &instant{
+		card: card{
+			name:     "Fog",
+			manaCost: mana{g: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{you},
+				effect:  addReplacement{replacement{event: damageDealt, combat: true, prevent: -1}},
+			},
+		},
+	}
+
+	magmaSpray = &instant{
+		card: card{
+			name:     "Magma Spray",
+			manaCost: mana{r: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{targetCreature},
+				effect: sequence{
+					damage{2},
+					addReplacement{replacement{event: dies, exile: true}},
+				},
+			},
+		},
+	}
+
+	laboratoryManiac = &creature{
+		card: card{
+			name:     "Laboratory Maniac",
+			manaCost: mana{c: 2, u: 1},
+			staticAbilities: []StaticAbility{
+				{replacement: &replacement{event: cardDrawn, you: true, emptyLibrary: true, instead: winGame{}}},
+			},
+		},
+		power:     2,
+		toughness: 2,
+	}
+
+	rhoxFaithmender = &creature{
+		card: card{
+			name:     "Rhox Faithmender",
+			manaCost: mana{c: 3, w: 1},
+			staticAbilities: []StaticAbility{
+				{replacement: &replacement{event: lifeGained, you: true, double: true}},
+			},
+		},
+		power:     1,
+		toughness: 5,
+		keywords:  lifelink,
+	}
+
+	gloriousAnthem = &enchantment{
+		card: card{
+			name:     "Glorious Anthem",
+			manaCost: mana{c: 1, w: 2},
+			staticAbilities: []StaticAbility{
+				{effect: &continuousEffect{layer: modifyPTLayer, scope: scopeCreaturesYouControl, power: 1, toughness: 1}},
+			},
+		},
+	}
+
+	holyStrength = &aura{
+		card: card{
+			name:     "Holy Strength",
+			manaCost: mana{w: 1},
+			staticAbilities: []StaticAbility{
+				{effect: &continuousEffect{layer: modifyPTLayer, scope: scopeAttached, power: 1, toughness: 2}},
+			},
+		},
+		enchant: targetCreature,
+	}
+
+	pacifism = &aura{
+		card: card{
+			name:     "Pacifism",
+			manaCost: mana{c: 1, w: 1},
+			staticAbilities: []StaticAbility{
+				{effect: &continuousEffect{layer: abilityLayer, scope: scopeAttached, cantAttack: true, cantBlock: true}},
+			},
+		},
+		enchant: targetCreature,
+	}
+
+	bonesplitter = &equipment{
+		card: card{
+			name:     "Bonesplitter",
+			manaCost: mana{c: 1},
+			staticAbilities: []StaticAbility{
+				{effect: &continuousEffect{layer: modifyPTLayer, scope: scopeAttached, power: 2}},
+			},
+			activatedAbilities: []ActivatedAbility{
+				{
+					cost: cost{mana: mana{c: 1}},
+					ability: ability{
+						targets: []targetType{targetCreatureYouControl},
+						effect:  attach{},
+					},
+					sorcerySpeed: true,
+				},
+			},
+		},
+	}
+
+	jayemdaeTome = &artifact{
+		card: card{
+			name:     "Jayemdae Tome",
+			manaCost: mana{c: 4},
+			activatedAbilities: []ActivatedAbility{
+				{
+					cost: cost{mana: mana{c: 4}, tap: true},
+					ability: ability{
+						targets: []targetType{you},
+						effect:  draw{1},
+					},
+				},
+			},
+		},
+	}
+
+	jaceBeleren = &planeswalker{
+		card: card{
+			name:      "Jace Beleren",
+			manaCost:  mana{c: 1, u: 2},
+			legendary: true,
+			activatedAbilities: []ActivatedAbility{
+				{
+					cost: cost{loyalty: 2, loyaltyAbility: true},
+					ability: ability{
+						targets: []targetType{eachPlayer},
+						effect:  draw{1},
+					},
+				},
+				{
+					cost: cost{loyalty: -1, loyaltyAbility: true},
+					ability: ability{
+						targets: []targetType{targetPlayer},
+						effect:  draw{1},
+					},
+				},
+				{
+					cost: cost{loyalty: -10, loyaltyAbility: true},
+					ability: ability{
+						targets: []targetType{targetPlayer},
+						effect:  mill{20},
+					},
+				},
+			},
+		},
+		loyalty: 3,
+	}
+
+	// token definitions, created by effects rather than cast from a hand
+	goblinToken = &creature{
+		card: card{
+			name:  "Goblin",
+			token: true,
+		},
+		power:     1,
+		toughness: 1,
+	}
+
+	soldierToken = &creature{
+		card: card{
+			name:  "Soldier",
+			token: true,
+		},
+		power:     1,
+		toughness: 1,
+	}
+
+	dragonFodder = &sorcery{
+		card: card{
+			name:     "Dragon Fodder",
+			manaCost: mana{c: 1, r: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{you},
+				effect:  createToken{token: goblinToken, amount: 2},
+			},
+		},
+	}
+
+	raiseTheAlarm = &instant{
+		card: card{
+			name:     "Raise the Alarm",
+			manaCost: mana{c: 1, w: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{you},
+				effect:  createToken{token: soldierToken, amount: 2},
+			},
+		},
+	}
+
+	goblinInstigator = &creature{
+		card: card{
+			name:     "Goblin Instigator",
+			manaCost: mana{c: 1, r: 1},
+			triggeredAbilities: []TriggeredAbility{
+				{
+					trigger: trigger{event: entersTheBattlefield, self: true},
+					ability: ability{
+						targets: []targetType{you},
+						effect:  createToken{token: goblinToken, amount: 1},
+					},
+				},
+			},
+		},
+		power:     1,
+		toughness: 1,
+	}
+
+	cacklingCounterpart = &instant{
+		card: card{
+			name:     "Cackling Counterpart",
+			manaCost: mana{c: 1, u: 2},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{targetCreatureYouControl},
+				effect:  createTokenCopy{},
+			},
+		},
+	}
+
+	glistenerElf = &creature{
+		card: card{
+			name:     "Glistener Elf",
+			manaCost: mana{g: 1},
+		},
+		power:     1,
+		toughness: 1,
+		keywords:  infect,
+	}
+
+	battlegrowth = &instant{
+		card: card{
+			name:     "Battlegrowth",
+			manaCost: mana{g: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{targetCreature},
+				effect:  putCounters{counter: plusOneCounter, amount: 1},
+			},
+		},
+	}
+
+	swamp = &land{
+		card: card{
+			name: "Swamp",
+			activatedAbilities: []ActivatedAbility{
+				{
+					cost: cost{tap: true},
+					ability: ability{
+						targets: []targetType{you},
+						effect:  addMana{amount: mana{b: 1}},
+					},
+				},
+			},
+		},
+		basic: true,
+	}
+
+	volcanicIsland = &land{
+		card: card{
+			name: "Volcanic Island",
+			activatedAbilities: []ActivatedAbility{
+				{
+					cost: cost{tap: true},
+					ability: ability{
+						targets: []targetType{you},
+						effect:  addMana{amount: mana{u: 1}},
+					},
+				},
+				{
+					cost: cost{tap: true},
+					ability: ability{
+						targets: []targetType{you},
+						effect:  addMana{amount: mana{r: 1}},
+					},
+				},
+			},
+		},
+	}
+
+	// a spell that adds mana is not a mana ability: it uses the stack
+	darkRitual = &instant{
+		card: card{
+			name:     "Dark Ritual",
+			manaCost: mana{b: 1},
+		},
+		spellAbility: SpellAbility{
+			ability{
+				targets: []targetType{you},
+				effect:  addMana{amount: mana{b: 3}},
+			},
+		},
+	}
+
 	cards = map[string]Card{
-		mountain.name:         mountain,
-		lavaSpike.name:        lavaSpike,
-		flameRift.name:        flameRift,
-		falkenrathReaver.name: falkenrathReaver,
+		mountain.name:            mountain,
+		lavaSpike.name:           lavaSpike,
+		flameRift.name:           flameRift,
+		shock.name:               shock,
+		counterspell.name:        counterspell,
+		murder.name:              murder,
+		unsummon.name:            unsummon,
+		falkenrathReaver.name:    falkenrathReaver,
+		prodigalPyromancer.name:  prodigalPyromancer,
+		moggFanatic.name:         moggFanatic,
+		flametongueKavu.name:     flametongueKavu,
+		guttersnipe.name:         guttersnipe,
+		goblinArsonist.name:      goblinArsonist,
+		bruteForce.name:          bruteForce,
+		benalishMarshal.name:     benalishMarshal,
+		ogreTaskmaster.name:      ogreTaskmaster,
+		baral.name:               baral,
+		serraAngel.name:          serraAngel,
+		ragingGoblin.name:        ragingGoblin,
+		vampireNighthawk.name:    vampireNighthawk,
+		borosSwiftblade.name:     borosSwiftblade,
+		giantSpider.name:         giantSpider,
+		colossalDreadmaw.name:    colossalDreadmaw,
+		boggartBrute.name:        boggartBrute,
+		youthfulKnight.name:      youthfulKnight,
+		fog.name:                 fog,
+		magmaSpray.name:          magmaSpray,
+		laboratoryManiac.name:    laboratoryManiac,
+		rhoxFaithmender.name:     rhoxFaithmender,
+		gloriousAnthem.name:      gloriousAnthem,
+		holyStrength.name:        holyStrength,
+		pacifism.name:            pacifism,
+		bonesplitter.name:        bonesplitter,
+		jayemdaeTome.name:        jayemdaeTome,
+		jaceBeleren.name:         jaceBeleren,
+		dragonFodder.name:        dragonFodder,
+		raiseTheAlarm.name:       raiseTheAlarm,
+		goblinInstigator.name:    goblinInstigator,
+		cacklingCounterpart.name: cacklingCounterpart,
+		glistenerElf.name:        glistenerElf,
+		battlegrowth.name:        battlegrowth,
+		swamp.name:               swamp,
+		darkRitual.name:          darkRitual,
+		volcanicIsland.name:      volcanicIsland,
+	}
+
+	// 903.5 a singleton deck of 100 cards led by Baral
+	commanderDeckList = unorderedCards{
+		baral:        1,
+		counterspell: 1,
+		unsummon:     1,
+		divination:   1,
+		jaceBeleren:  1,
+		jayemdaeTome: 1,
+		bonesplitter: 1,
+		island:       93,
 	}
 
 	deckList = unorderedCards{
@@ -0,0 +1,228 @@
+package main
+
+import "sort"
+
+// 611.1 A continuous effect modifies characteristics of objects, modifies control of objects,
+// or affects players or the rules of the game, for a fixed or indefinite period.
+// 613.1 The values of an object's characteristics are determined by starting with the actual
+// object [...] then applying continuous effects in a series of layers in the following order:
+// we only model the layers that our cards use.
+
+type layer int
+
+const (
+	// 613.1f Layer 6: Ability-adding effects, ability-removing effects
+	abilityLayer layer = iota
+	// 613.4b Layer 7b: Effects that set power and/or toughness to a specific number or value
+	setPTLayer
+	// 613.4c Layer 7c: Effects and counters that modify power and/or toughness
+	modifyPTLayer
+)
+
+// which objects a continuous effect applies to, relative to its source
+type scope int
+
+const (
+	// the source itself, i.e. 'this creature can't block'
+	scopeSelf scope = iota
+	// 'creatures you control get +1/+1'
+	scopeCreaturesYouControl
+	// 'other creatures you control get +1/+1'
+	scopeOtherCreaturesYouControl
+	// 'creatures your opponents control can't block'
+	scopeCreaturesOpponentsControl
+	// 'creatures can't block'
+	scopeAllCreatures
+	// the permanent the source is attached to, i.e. 'enchanted creature gets +1/+2'
+	scopeAttached
+)
+
+type continuousEffect struct {
+	layer layer
+	scope scope
+	// layer 7b and 7c
+	power, toughness int
+	// layer 6
+	cantAttack bool
+	cantBlock  bool
+	keywords   keyword
+}
+
+type duration int
+
+const (
+	// as long as the source of a static ability is on the battlefield
+	whileOnBattlefield duration = iota
+	// 514.2 ends during the cleanup step
+	untilEndOfTurn
+)
+
+// a continuous effect created by a resolving spell or ability,
+// as opposed to one generated by a static ability.
+// 611.2c [it] affects only objects on the battlefield at the time it resolved,
+// so we determine the ids of the affected objects once.
+type activeEffect struct {
+	effect    continuousEffect
+	ids       []uint64
+	duration  duration
+	timestamp int
+}
+
+// characteristics of a permanent after applying continuous effects
+type characteristics struct {
+	power, toughness int
+	cantAttack       bool
+	cantBlock        bool
+	keywords         keyword
+}
+
+func (e continuousEffect) affects(source cardInstance, controller int, c cardInstance, cController int) bool {
+	if _, ok := c.card.(*creature); !ok && e.scope != scopeSelf {
+		return false
+	}
+	switch e.scope {
+	case scopeSelf:
+		return source.id == c.id
+	case scopeCreaturesYouControl:
+		return controller == cController
+	case scopeOtherCreaturesYouControl:
+		return controller == cController && source.id != c.id
+	case scopeCreaturesOpponentsControl:
+		return controller != cController
+	case scopeAllCreatures:
+		return true
+	case scopeAttached:
+		return source.attachedTo != 0 && source.attachedTo == c.id
+	}
+	return false
+}
+
+// 613.7 Within a layer or sublayer, determining which order effects are applied in
+// is usually done using a timestamp system. An effect with an earlier timestamp is
+// applied before an effect with a later timestamp.
+func (g *game) characteristics(c cardInstance) characteristics {
+	ch := characteristics{}
+	if cr, ok := c.card.(*creature); ok {
+		ch.power, ch.toughness = cr.power, cr.toughness
+		ch.keywords = cr.keywords
+	}
+	_, cController := g.findPermanent(c.id)
+	type timestamped struct {
+		effect    continuousEffect
+		timestamp int
+	}
+	effects := []timestamped{}
+	for i, p := range g.players {
+		for _, source := range p.permanents() {
+			for _, sa := range source.card.getStaticAbilities() {
+				if sa.effect == nil || !sa.effect.affects(source, i, c, cController) {
+					continue
+				}
+				effects = append(effects, timestamped{*sa.effect, source.timestamp})
+			}
+		}
+	}
+	for _, ae := range g.continuousEffects {
+		for _, id := range ae.ids {
+			if id == c.id {
+				effects = append(effects, timestamped{ae.effect, ae.timestamp})
+			}
+		}
+	}
+	sort.SliceStable(effects, func(i, j int) bool {
+		if effects[i].effect.layer != effects[j].effect.layer {
+			return effects[i].effect.layer < effects[j].effect.layer
+		}
+		return effects[i].timestamp < effects[j].timestamp
+	})
+	for _, e := range effects {
+		switch e.effect.layer {
+		case abilityLayer:
+			ch.cantAttack = ch.cantAttack || e.effect.cantAttack
+			ch.cantBlock = ch.cantBlock || e.effect.cantBlock
+			ch.keywords |= e.effect.keywords
+		case setPTLayer:
+			ch.power, ch.toughness = e.effect.power, e.effect.toughness
+		case modifyPTLayer:
+			ch.power += e.effect.power
+			ch.toughness += e.effect.toughness
+		}
+	}
+	// 613.4c [...] counters that modify power and/or toughness;
+	// all of layer 7c adds up, so the order within the layer does not matter
+	n := c.counters[plusOneCounter] - c.counters[minusOneCounter]
+	ch.power += n
+	ch.toughness += n
+	return ch
+}
+
+func (g *game) power(c cardInstance) int {
+	return g.characteristics(c).power
+}
+
+func (g *game) toughness(c cardInstance) int {
+	return g.characteristics(c).toughness
+}
+
+func (g *game) hasKeyword(c cardInstance, kw keyword) bool {
+	return g.characteristics(c).keywords.has(kw)
+}
+
+// 601.2f The player determines the total cost of the spell [...]
+// the mana cost plus all additional costs and cost increases, and less all cost reductions.
+// Cost reductions only reduce the generic part of the cost.
+func (g *game) manaCost(pindex int, c Card) mana {
+	cost := c.getManaCost()
+	for _, source := range g.getPlayer(pindex).permanents() {
+		for _, sa := range source.card.getStaticAbilities() {
+			if sa.costReduction == 0 {
+				continue
+			}
+			if sa.costFilter != nil && !sa.costFilter(c) {
+				continue
+			}
+			cost.c -= sa.costReduction
+			if cost.c < 0 {
+				cost.c = 0
+			}
+		}
+	}
+	return cost
+}
+
+// addEffect starts a continuous effect from a resolving spell or ability
+// controlled by controller, locking in the objects it affects
+func (g *game) addEffect(e continuousEffect, controller int, ids []uint64, d duration) {
+	if ids == nil {
+		for i, p := range g.players {
+			for _, c := range p.permanents() {
+				if e.affects(cardInstance{}, controller, c, i) {
+					ids = append(ids, c.id)
+				}
+			}
+		}
+	}
+	g.continuousEffects = append(g.continuousEffects, activeEffect{
+		effect:    e,
+		ids:       ids,
+		duration:  d,
+		timestamp: g.nextTimestamp(),
+	})
+}
+
+func (g *game) nextTimestamp() int {
+	g.timestamp++
+	return g.timestamp
+}
+
+// 514.2 [...] and all "until end of turn" and "this turn" effects end.
+func (g *game) endUntilEndOfTurnEffects() {
+	var effects []activeEffect
+	for _, ae := range g.continuousEffects {
+		if ae.duration == untilEndOfTurn {
+			continue
+		}
+		effects = append(effects, ae)
+	}
+	g.continuousEffects = effects
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCharacteristics(t *testing.T) {
+	setTo1 := continuousEffect{layer: setPTLayer, power: 1, toughness: 1}
+	for i, tt := range []struct {
+		name    string
+		self    []cardInstance
+		opp     []cardInstance
+		effects []activeEffect
+		want    characteristics
+	}{
+		{
+			name: "printed power and toughness",
+			self: []cardInstance{{id: 1, card: falkenrathReaver}},
+			want: characteristics{power: 2, toughness: 2},
+		},
+		{
+			name: "anthem",
+			self: []cardInstance{{id: 1, card: falkenrathReaver}, {id: 2, card: benalishMarshal}},
+			want: characteristics{power: 3, toughness: 3},
+		},
+		{
+			name: "opponent's anthem",
+			self: []cardInstance{{id: 1, card: falkenrathReaver}},
+			opp:  []cardInstance{{id: 2, card: benalishMarshal}},
+			want: characteristics{power: 2, toughness: 2},
+		},
+		{
+			name: "can't block",
+			self: []cardInstance{{id: 1, card: ogreTaskmaster}},
+			want: characteristics{power: 4, toughness: 3, cantBlock: true},
+		},
+		{
+			name:    "pump until end of turn",
+			self:    []cardInstance{{id: 1, card: falkenrathReaver}},
+			effects: []activeEffect{{effect: continuousEffect{layer: modifyPTLayer, power: 3, toughness: 3}, ids: []uint64{1}, duration: untilEndOfTurn, timestamp: 1}},
+			want:    characteristics{power: 5, toughness: 5},
+		},
+		{
+			name: "setting power and toughness applies before modifying, regardless of timestamp",
+			self: []cardInstance{{id: 1, card: falkenrathReaver}, {id: 2, card: benalishMarshal, timestamp: 1}},
+			effects: []activeEffect{
+				{effect: continuousEffect{layer: modifyPTLayer, power: 3, toughness: 3}, ids: []uint64{1}, timestamp: 2},
+				{effect: setTo1, ids: []uint64{1}, timestamp: 3},
+			},
+			want: characteristics{power: 5, toughness: 5},
+		},
+		{
+			name: "+1/+1 counters",
+			self: []cardInstance{{id: 1, card: falkenrathReaver, counters: map[counterType]int{plusOneCounter: 2, minusOneCounter: 1}}},
+			want: characteristics{power: 3, toughness: 3},
+		},
+		{
+			name:    "counters apply after setting power and toughness",
+			self:    []cardInstance{{id: 1, card: falkenrathReaver, counters: map[counterType]int{plusOneCounter: 1}}},
+			effects: []activeEffect{{effect: setTo1, ids: []uint64{1}, timestamp: 1}},
+			want:    characteristics{power: 2, toughness: 2},
+		},
+	} {
+		g := &game{
+			players: []*player{
+				SELF: &player{battlefield: battlefield{creatures: tt.self}},
+				OPP:  &player{battlefield: battlefield{creatures: tt.opp}},
+			},
+			numPlayers:        2,
+			continuousEffects: tt.effects,
+		}
+		got := g.characteristics(tt.self[0])
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: %s) got %+v want %+v", i, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEndUntilEndOfTurnEffects(t *testing.T) {
+	c := cardInstance{id: 1, card: falkenrathReaver}
+	g := &game{
+		players:    []*player{SELF: &player{battlefield: battlefield{creatures: []cardInstance{c}}}, OPP: &player{}},
+		numPlayers: 2,
+	}
+	pump{power: 3, toughness: 3}.apply(g, []effectTarget{{id: 1, ttype: targetCreature}})
+	if got := g.power(c); got != 5 {
+		t.Errorf("power: got %d want %d", got, 5)
+	}
+	g.cleanupStep()
+	if got := g.power(c); got != 2 {
+		t.Errorf("power after cleanup: got %d want %d", got, 2)
+	}
+}
+
+func TestManaCost(t *testing.T) {
+	for i, tt := range []struct {
+		card Card
+		want mana
+	}{
+		{card: divination, want: mana{c: 1, u: 1}},
+		{card: lavaSpike, want: mana{r: 1}},
+		{card: falkenrathReaver, want: mana{c: 1, r: 1}},
+	} {
+		g := &game{
+			players:    []*player{SELF: &player{battlefield: battlefield{creatures: []cardInstance{{id: 1, card: baral}}}}, OPP: &player{}},
+			numPlayers: 2,
+		}
+		got := g.manaCost(SELF, tt.card)
+		if got != tt.want {
+			t.Errorf("%d) got %v want %v", i, got, tt.want)
+		}
+	}
+}
@@ -1,20 +1,45 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"math/rand"
+	"os"
 	"time"
 )
 
 func main() {
+	numPlayers := flag.Int("players", 2, "number of players in a free-for-all game")
+	commander := flag.Bool("commander", false, "play the Commander variant")
+	flag.Parse()
+	// the second player is played by minimax, and there is nobody to play against alone
+	if *numPlayers < 2 {
+		fmt.Println("a game needs at least 2 players")
+		os.Exit(1)
+	}
 	rand.Seed(time.Now().UnixNano())
 
-	p1 := newPlayer(0, "player1", deckList)
-	p2 := newPlayer(1, "player2", deckList)
-	p1.strategy = simpleStrategy{}
-	p2.strategy = minmaxStrategy{}
+	players := make([]*player, *numPlayers)
+	for i := range players {
+		name := fmt.Sprintf("player%d", i+1)
+		if !*commander {
+			players[i] = newPlayer(i, name, deckList)
+		} else {
+			// every player gets the same deck on purpose: Baral is the only legendary creature
+			// in the card pool so far. Other decks are validated in commander_test.go
+			p, err := newCommanderPlayer(i, name, baral, commanderDeckList)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			players[i] = p
+		}
+		players[i].strategy = simpleStrategy{}
+	}
+	players[1].strategy = minmaxStrategy{}
 
-	startingPlayer := rand.Intn(2)
+	startingPlayer := rand.Intn(*numPlayers)
 
-	game := newGame(startingPlayer, p1, p2)
+	game := newGame(startingPlayer, players...)
 	game.loop()
 }
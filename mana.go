@@ -0,0 +1,170 @@
+package main
+
+// a manaSource is a permanent that can be tapped for mana,
+// with an option for each of its mana abilities, i.e. a dual land has two
+type manaSource struct {
+	id      uint64
+	options []manaOption
+}
+
+type manaOption struct {
+	// index: in source card.activatedAbilities
+	index  int
+	amount mana
+}
+
+// a manaPayment lists the mana abilities to activate, one per source
+type manaPayment []manaActivation
+
+type manaActivation struct {
+	id    uint64
+	index int
+}
+
+// a partialPayment is the best payment found for the sources from some index onwards.
+// flexibility counts the options given up by tapping its sources:
+// tapping a dual land closes off more than tapping a basic land
+type partialPayment struct {
+	payment     manaPayment
+	flexibility int
+	ok          bool
+}
+
+func (pp partialPayment) better(other partialPayment) bool {
+	if !pp.ok || !other.ok {
+		return pp.ok
+	}
+	if len(pp.payment) != len(other.payment) {
+		return len(pp.payment) < len(other.payment)
+	}
+	return pp.flexibility < other.flexibility
+}
+
+type paymentState struct {
+	i    int
+	pool mana
+}
+
+// solveManaPayment finds which sources to tap so that together with the mana pool the cost is covered,
+// returning false if there is no such payment. Mana floating in the pool is always used first.
+// Of all valid payments it keeps the most future options open: it taps as few sources as possible,
+// and of those prefers the ones with the fewest options. Ties go to the earliest sources,
+// so the outcome is deterministic.
+// Both add up per source, so it goes over the sources in order and remembers the best payment
+// from each source onwards for each pool, capped at what could be spent on the cost.
+func solveManaPayment(pool mana, sources []manaSource, cost mana) (manaPayment, bool) {
+	if pool.covers(cost) {
+		return manaPayment{}, true
+	}
+	if !reachOf(sources).canCover(pool, cost) {
+		return nil, false
+	}
+	memo := map[paymentState]partialPayment{}
+	var solve func(i int, pool mana) partialPayment
+	solve = func(i int, pool mana) partialPayment {
+		if pool.covers(cost) {
+			return partialPayment{payment: manaPayment{}, ok: true}
+		}
+		if i == len(sources) {
+			return partialPayment{}
+		}
+		state := paymentState{i: i, pool: pool}
+		if pp, ok := memo[state]; ok {
+			return pp
+		}
+		s := sources[i]
+		var best partialPayment
+		for _, o := range s.options {
+			rest := solve(i+1, pool.add(o.amount).capTo(cost))
+			if !rest.ok {
+				continue
+			}
+			pp := partialPayment{
+				payment:     append(manaPayment{{id: s.id, index: o.index}}, rest.payment...),
+				flexibility: len(s.options) + rest.flexibility,
+				ok:          true,
+			}
+			if pp.better(best) {
+				best = pp
+			}
+		}
+		if rest := solve(i+1, pool); rest.better(best) {
+			best = rest
+		}
+		memo[state] = best
+		return best
+	}
+	best := solve(0, pool.capTo(cost))
+	return best.payment, best.ok
+}
+
+// capTo caps the mana at what could be spent on the cost: colored mana beyond what the cost
+// needs of that color and of generic mana, or colorless mana beyond the generic cost, makes no difference
+func (m mana) capTo(cost mana) mana {
+	return mana{
+		c: minInt(m.c, cost.c),
+		w: minInt(m.w, cost.w+cost.c),
+		u: minInt(m.u, cost.u+cost.c),
+		b: minInt(m.b, cost.b+cost.c),
+		r: minInt(m.r, cost.r+cost.c),
+		g: minInt(m.g, cost.g+cost.c),
+	}
+}
+
+// a manaReach is the most mana the sources could add: of each color and in total.
+// It is an upper bound, since a dual land counts towards both of its colors
+type manaReach struct {
+	colors mana
+	total  int
+}
+
+func reachOf(sources []manaSource) manaReach {
+	r := manaReach{}
+	for _, s := range sources {
+		most, total := mana{}, 0
+		for _, o := range s.options {
+			a := o.amount
+			most = mana{c: maxInt(most.c, a.c), w: maxInt(most.w, a.w), u: maxInt(most.u, a.u), b: maxInt(most.b, a.b), r: maxInt(most.r, a.r), g: maxInt(most.g, a.g)}
+			total = maxInt(total, a.converted())
+		}
+		r.colors = r.colors.add(most)
+		r.total += total
+	}
+	return r
+}
+
+// canCover checks whether the pool together with what the sources could add might pay the cost,
+// so that the search can be skipped when not even the best case is enough
+func (r manaReach) canCover(pool mana, cost mana) bool {
+	m := pool.add(r.colors)
+	if m.w < cost.w || m.u < cost.u || m.b < cost.b || m.r < cost.r || m.g < cost.g {
+		return false
+	}
+	return pool.converted()+r.total >= cost.converted()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// payOptimal activates the mana abilities found by solveManaPayment.
+// assumption: player has the mana to pay, see hasMana
+func payOptimal(p *player, g *game, cost mana) {
+	payment, ok := solveManaPayment(p.manaPool, p.manaSources(), cost)
+	if !ok {
+		panic("not enough mana to pay the cost")
+	}
+	for _, a := range payment {
+		g.activateManaAbility(p.idx, a.id, a.index)
+	}
+}
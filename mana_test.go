@@ -0,0 +1,128 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSolveManaPayment(t *testing.T) {
+	volcanic := manaSource{id: 1, options: []manaOption{{index: 0, amount: mana{u: 1}}, {index: 1, amount: mana{r: 1}}}}
+	mountain := manaSource{id: 2, options: []manaOption{{index: 0, amount: mana{r: 1}}}}
+	island := manaSource{id: 3, options: []manaOption{{index: 0, amount: mana{u: 1}}}}
+	// a board full of lands, i.e. in a commander game, ending with an island
+	var board []manaSource
+	for i := 0; i < 39; i++ {
+		board = append(board, manaSource{id: uint64(10 + i), options: mountain.options})
+	}
+	board = append(board, island)
+	for i, tt := range []struct {
+		name    string
+		pool    mana
+		sources []manaSource
+		cost    mana
+		want    manaPayment
+		wantOK  bool
+	}{
+		{
+			name:   "nothing to pay with",
+			cost:   mana{r: 1},
+			wantOK: false,
+		},
+		{
+			name:    "floating mana pays without tapping",
+			pool:    mana{r: 1},
+			sources: []manaSource{mountain},
+			cost:    mana{r: 1},
+			want:    manaPayment{},
+			wantOK:  true,
+		},
+		{
+			name:    "dual land pays the color the basic can't",
+			sources: []manaSource{volcanic, mountain},
+			cost:    mana{u: 1, r: 1},
+			want:    manaPayment{{id: 1, index: 0}, {id: 2, index: 0}},
+			wantOK:  true,
+		},
+		{
+			name:    "keep the dual land untapped if possible",
+			sources: []manaSource{volcanic, mountain},
+			cost:    mana{r: 1},
+			want:    manaPayment{{id: 2, index: 0}},
+			wantOK:  true,
+		},
+		{
+			name:    "generic mana is paid with the least flexible source",
+			sources: []manaSource{volcanic, island, mountain},
+			cost:    mana{c: 1, u: 1},
+			want:    manaPayment{{id: 3, index: 0}, {id: 2, index: 0}},
+			wantOK:  true,
+		},
+		{
+			name:    "not enough colored sources",
+			sources: []manaSource{volcanic, island},
+			cost:    mana{r: 2},
+			wantOK:  false,
+		},
+		{
+			name:    "floating mana counts towards generic costs",
+			pool:    mana{b: 1},
+			sources: []manaSource{volcanic, island},
+			cost:    mana{c: 1, r: 1},
+			want:    manaPayment{{id: 1, index: 1}},
+			wantOK:  true,
+		},
+		{
+			name:    "no payment among many sources",
+			sources: board[:39],
+			cost:    mana{u: 1},
+			wantOK:  false,
+		},
+		{
+			name:    "only the last of many sources pays the color",
+			sources: board,
+			cost:    mana{u: 1},
+			want:    manaPayment{{id: 3, index: 0}},
+			wantOK:  true,
+		},
+		{
+			name:    "generic mana from many sources",
+			sources: board,
+			cost:    mana{c: 5, u: 1},
+			want:    manaPayment{{id: 10, index: 0}, {id: 11, index: 0}, {id: 12, index: 0}, {id: 13, index: 0}, {id: 14, index: 0}, {id: 3, index: 0}},
+			wantOK:  true,
+		},
+	} {
+		got, ok := solveManaPayment(tt.pool, tt.sources, tt.cost)
+		if ok != tt.wantOK {
+			t.Errorf("%d: %s) got ok %t want %t", i, tt.name, ok, tt.wantOK)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: %s) got %v want %v", i, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPayOptimal(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{
+				battlefield: battlefield{lands: []cardInstance{{id: 1, card: volcanicIsland}, {id: 2, card: mountain}}},
+			},
+			OPP: &player{},
+		},
+		numPlayers: 2,
+	}
+	p := g.getPlayer(SELF)
+	cost := mana{u: 1, r: 1}
+	if !p.hasMana(cost) {
+		t.Fatalf("player should be able to pay %v", cost)
+	}
+	payOptimal(p, g, cost)
+	if p.manaPool != cost {
+		t.Errorf("got pool %v want %v", p.manaPool, cost)
+	}
+	if len(p.manaSources()) != 0 {
+		t.Errorf("all lands should be tapped")
+	}
+}
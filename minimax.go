@@ -8,6 +8,8 @@ import (
 // plies are turn segments where the player holds priority
 
 // legacy: minmax is probably not feasible to use
+// Only actions, attacks and blocks are searched. All other choices, such as targets for
+// triggered abilities or which legendary permanent to keep, use the same heuristics as simpleStrategy.
 type minmaxStrategy struct{}
 
 func (minmaxStrategy) NextAction(_ *player, g *game) Action {
@@ -18,13 +20,43 @@ func (minmaxStrategy) Attacks(p *player, g *game) attackAction {
 	return startMinimax(g).(attackAction)
 }
 
-func (minmaxStrategy) PayManaCost(p *player, cost mana) {
-	payNaive(p, cost)
+func (minmaxStrategy) Blocks(p *player, g *game) blockAction {
+	return startMinimax(g).(blockAction)
+}
+
+func (minmaxStrategy) ChooseTargets(p *player, g *game, options [][]effectTarget) []effectTarget {
+	return chooseHostileTargets(p, g, options)
+}
+
+func (minmaxStrategy) ChooseReplacement(p *player, g *game, options []replacement) int {
+	return choosePrevention(options)
+}
+
+func (minmaxStrategy) ChooseLegend(p *player, g *game, legends []cardInstance) uint64 {
+	return keepNewestLegend(legends)
+}
+
+func (minmaxStrategy) Discard(p *player, g *game, n int) []Card {
+	return discardHighestCost(p, g, n)
+}
+
+func (minmaxStrategy) Mulligan(hand unorderedCards, mulligansTaken int) bool {
+	return mulliganOnLandCount(hand, mulligansTaken)
+}
+
+func (minmaxStrategy) ChooseBottom(p *player, g *game, n int) []Card {
+	return bottomKeepingBalance(p, g, n)
+}
+
+func (minmaxStrategy) PayManaCost(p *player, g *game, cost mana) {
+	payOptimal(p, g, cost)
 }
 
 // with perfect information, minmax refuses to play anything
 // if it knows it will lose anyways..
-var maxDepth = 30
+// steps in which nobody can act are skipped, so each ply looks further ahead
+// than the number suggests: the search covers multiple turns
+var maxDepth = 18
 
 type node struct {
 	game        *game
@@ -32,7 +64,7 @@ type node struct {
 }
 
 func startMinimax(g *game) Action {
-	root := node{game: g, pointOfView: g.priorityPlayer}
+	root := node{game: g, pointOfView: g.decisionPlayer()}
 	var a Action
 	bestValue := -math.MaxFloat64
 	for _, childAction := range root.getActionsSelf() {
@@ -72,12 +104,15 @@ func minimax(node node, depth int) float64 {
 }
 
 func (n node) maximizing() bool {
-	return n.pointOfView == n.game.priorityPlayer
+	return n.pointOfView == n.game.decisionPlayer()
 }
 
 func (n node) getChild(action Action) node {
 	g := n.game.copy()
 	g.resolveAction(action)
+	for _, i := range g.checkStateBasedActions() {
+		g.eliminate(i)
+	}
 	return node{
 		game:        g,
 		pointOfView: n.pointOfView,
@@ -85,13 +120,25 @@ func (n node) getChild(action Action) node {
 }
 
 func (n node) getActionsSelf() []Action {
-	return getActions(n.game, n.pointOfView)
+	return n.searchActions(n.pointOfView)
 }
 
+// paranoid assumption: every opponent plays to minimize our outcome
 func (n node) getActionsOpponent() []Action {
-	// again, two player game assumption for now
-	oppIndex := (n.pointOfView + 1) % 2
-	return getActions(n.game, oppIndex)
+	return n.searchActions(n.game.decisionPlayer())
+}
+
+// activating mana abilities in advance gains nothing over activating them
+// while paying a cost, see PayManaCost, so the search leaves them out
+func (n node) searchActions(index int) []Action {
+	actions := []Action{}
+	for _, a := range getActions(n.game, index) {
+		if n.game.isManaActivation(a) {
+			continue
+		}
+		actions = append(actions, a)
+	}
+	return actions
 }
 
 // use an arbitrarily large number because I
@@ -101,18 +148,25 @@ const infinity float64 = 1000000.0
 // evaluate payoff function
 func (n node) evaluate(depth int) float64 {
 	p := n.game.getPlayer(n.pointOfView)
-	opp := n.game.getOpponent(n.pointOfView)
-	if p.lifeTotal <= 0 || p.decked {
+	if p.losesGame() {
 		return -infinity
 	}
-	if opp.lifeTotal <= 0 || opp.decked {
+	opps := n.game.getOpponents(n.pointOfView)
+	if len(opps) == 0 {
 		// penalise long term plans: winning earlier is better!
 		return infinity - float64(-depth)
 	}
+	// compare against the opponent furthest from losing
+	opp := n.game.getPlayer(opps[0])
+	for _, i := range opps[1:] {
+		if o := n.game.getPlayer(i); o.lifeTotal > opp.lifeTotal {
+			opp = o
+		}
+	}
 
 	power := 0
 	for _, c := range p.battlefield.creatures {
-		power += c.card.(*creature).power
+		power += n.game.power(c)
 	}
 
 	// TODO: weights per feature
@@ -120,41 +174,57 @@ func (n node) evaluate(depth int) float64 {
 	return lifeDiff + float64(len(p.battlefield.lands)) - float64(-depth) + float64(power)*10
 }
 
-// does the game end in this configuration next state-based check?
+// does the game end for us in this configuration?
+// either we lost, or all opponents did
 func (n node) isTerminal() bool {
-	// NOTE: needs to only consider game ending state based actions
-	return n.game.checkStateBasedActions()
+	return n.game.getPlayer(n.pointOfView).losesGame() || len(n.game.getOpponents(n.pointOfView)) == 0
 }
 
 func getActions(g *game, index int) []Action {
 	actions := []Action{passAction{action{controller: index}}}
-	if g.currentStep == declareAttackersStep && g.declarations == 0 {
+	if g.declaringAttackers() {
 		return getAttacks(g, index)
 	}
+	if g.declaringBlockers() {
+		return getBlocks(g, index)
+	}
 	p := g.getPlayer(index)
 	for card, _ := range p.hand {
 		if !p.canPlayCard(g, card) {
 			continue
 		}
 		switch c := card.(type) {
-		case *sorcery:
-			// TODO: multiple targets
-			ttype := c.spellAbility.getTargets()[0]
-			if ttype.isUntargeted() {
-				actions = append(actions, cardAction{card: card, action: action{controller: index}, targets: []effectTarget{{ttype: ttype}}})
-				return actions
+		case *land:
+			actions = append(actions, playLandAction{card: card, action: action{controller: index}})
+		case spell:
+			for _, tt := range getTargets(g, c.getSpellAbility(), index) {
+				actions = append(actions, cardAction{card: card, action: action{controller: index}, targets: tt})
 			}
-			for _, tt := range getTargets(g, c.spellAbility, index) {
-				et := []effectTarget{}
-				for _, t := range tt {
-					et = append(et, effectTarget{index: t, ttype: ttype})
-				}
-				actions = append(actions, cardAction{card: card, action: action{controller: index}, targets: et})
+		case *aura:
+			for _, tt := range getTargets(g, c.enchantAbility(), index) {
+				actions = append(actions, cardAction{card: card, action: action{controller: index}, targets: tt})
 			}
 		default:
 			actions = append(actions, cardAction{card: card, action: action{controller: index}})
 		}
 	}
+	if p.canCastCommander(g) {
+		actions = append(actions, p.castCommander())
+	}
+	for _, c := range p.permanents() {
+		for i, aa := range c.card.getActivatedAbilities() {
+			if p.canActivateManaAbility(g, c, i) {
+				actions = append(actions, activateAction{action: action{controller: index}, id: c.id, index: i})
+				continue
+			}
+			if !p.canActivate(g, c, i) {
+				continue
+			}
+			for _, tt := range getTargets(g, aa, index) {
+				actions = append(actions, activateAction{action: action{controller: index}, id: c.id, index: i, targets: tt})
+			}
+		}
+	}
 	return actions
 }
 
@@ -162,17 +232,117 @@ func getAttacks(g *game, index int) []Action {
 	// TODO: first attempt, always attack with everything
 	// for minimax, this should return the superset of attackers instead
 	p := g.getPlayer(index)
-	return []Action{attackWithAll(p, index)}
+	actions := []Action{}
+	for _, opp := range g.getOpponents(index) {
+		actions = append(actions, attackWithAllAt(g, p, index, opp))
+		// 506.3c or attack a planeswalker controlled by the defending player instead
+		for _, c := range g.getPlayer(opp).battlefield.other {
+			if !isPlaneswalker(c.card) {
+				continue
+			}
+			a := attackWithAllAt(g, p, index, opp)
+			for i := range a.attackers {
+				a.attackers[i].planeswalker = c.id
+			}
+			actions = append(actions, a)
+		}
+	}
+	return actions
 }
 
-func possibleTargets(g *game, t targetType, controller int) []target {
+func getBlocks(g *game, index int) []Action {
+	// TODO: first attempt, either don't block or block a single attacker
+	// with a single creature. for minimax, this should return the superset of blocks
+	p := g.getPlayer(index)
+	actions := []Action{blockAction{action: action{controller: index}}}
+	attackers := g.getActivePlayer().battlefield.creatures
+	for _, b := range p.creaturesThatCanBlock(g) {
+		for _, att := range attackers {
+			if att.attacking != index {
+				continue
+			}
+			block := blockAction{action: action{controller: index}, blockers: []combatTarget{{id: b, blocks: att.id}}}
+			if !g.isLegalBlock(block) {
+				continue
+			}
+			actions = append(actions, block)
+		}
+	}
+	return actions
+}
+
+func possibleTargets(g *game, t targetType, controller int) []effectTarget {
 	switch t {
 	case you:
-		return []target{target(controller)}
+		return []effectTarget{{index: target(controller), ttype: t}}
 	case targetPlayer:
-		ts := []target{}
-		for i := 0; i < g.numPlayers; i++ {
-			ts = append(ts, target(i))
+		ts := []effectTarget{}
+		for _, i := range g.apnapOrder() {
+			ts = append(ts, effectTarget{index: target(i), ttype: t})
+		}
+		return ts
+	case targetCreature:
+		ts := []effectTarget{}
+		for _, p := range g.players {
+			for _, c := range p.battlefield.creatures {
+				ts = append(ts, effectTarget{id: c.id, ttype: t})
+			}
+		}
+		return ts
+	case targetCreatureYouControl:
+		ts := []effectTarget{}
+		for _, c := range g.getPlayer(controller).battlefield.creatures {
+			ts = append(ts, effectTarget{id: c.id, ttype: t})
+		}
+		return ts
+	case targetPermanent:
+		ts := []effectTarget{}
+		for _, p := range g.players {
+			for _, c := range p.permanents() {
+				ts = append(ts, effectTarget{id: c.id, ttype: t})
+			}
+		}
+		return ts
+	case anyTarget:
+		ts := []effectTarget{}
+		for _, i := range g.apnapOrder() {
+			ts = append(ts, effectTarget{index: target(i), ttype: t})
+		}
+		for _, p := range g.players {
+			for _, c := range p.battlefield.creatures {
+				ts = append(ts, effectTarget{id: c.id, ttype: t})
+			}
+			for _, c := range p.battlefield.other {
+				if isPlaneswalker(c.card) {
+					ts = append(ts, effectTarget{id: c.id, ttype: t})
+				}
+			}
+		}
+		return ts
+	case targetPlayerOrPlaneswalker:
+		ts := []effectTarget{}
+		for _, i := range g.apnapOrder() {
+			ts = append(ts, effectTarget{index: target(i), ttype: t})
+		}
+		for _, p := range g.players {
+			for _, c := range p.battlefield.other {
+				if isPlaneswalker(c.card) {
+					ts = append(ts, effectTarget{id: c.id, ttype: t})
+				}
+			}
+		}
+		return ts
+	case eachPlayer:
+		return []effectTarget{{ttype: t}}
+	case eachOpponent:
+		return []effectTarget{{index: target(controller), ttype: t}}
+	case targetSpell:
+		ts := []effectTarget{}
+		for _, a := range g.stack {
+			if !isSpell(a) {
+				continue
+			}
+			ts = append(ts, effectTarget{id: a.getID(), ttype: t})
 		}
 		return ts
 	}
@@ -180,15 +350,18 @@ func possibleTargets(g *game, t targetType, controller int) []target {
 }
 
 // TODO: multiple targets
-func getTargets(g *game, a Ability, controller int) [][]target {
+func getTargets(g *game, a Ability, controller int) [][]effectTarget {
+	if len(a.getTargets()) == 0 {
+		return [][]effectTarget{nil}
+	}
 	targets := possibleTargets(g, a.getTargets()[0], controller)
 	if len(targets) == 0 {
 		return nil
 	}
 	// generate superset of targets
-	superset := [][]target{}
+	superset := [][]effectTarget{}
 	for _, t := range targets {
-		superset = append(superset, []target{t})
+		superset = append(superset, []effectTarget{t})
 	}
 	return superset
 }
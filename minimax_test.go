@@ -12,25 +12,68 @@ func TestPossibleTargets(t *testing.T) {
 		target     targetType
 		controller int
 		game       *game
-		want       []target
+		want       []effectTarget
 	}{
 		{
 			target:     you,
 			controller: SELF,
-			game:       &game{numPlayers: 2},
-			want:       []target{target(SELF)},
+			game:       &game{numPlayers: 2, players: []*player{SELF: &player{}, OPP: &player{}}},
+			want:       []effectTarget{{index: target(SELF), ttype: you}},
 		},
 		{
 			target:     targetPlayer,
 			controller: SELF,
-			game:       &game{numPlayers: 2},
-			want:       []target{target(SELF), target(OPP)},
+			game:       &game{numPlayers: 2, players: []*player{SELF: &player{}, OPP: &player{}}},
+			want:       []effectTarget{{index: target(SELF), ttype: targetPlayer}, {index: target(OPP), ttype: targetPlayer}},
 		},
 		{
 			target:     targetPlayer,
 			controller: OPP,
-			game:       &game{numPlayers: 2},
-			want:       []target{target(SELF), target(OPP)},
+			game:       &game{numPlayers: 2, players: []*player{SELF: &player{}, OPP: &player{}}},
+			want:       []effectTarget{{index: target(SELF), ttype: targetPlayer}, {index: target(OPP), ttype: targetPlayer}},
+		},
+		{
+			target:     targetSpell,
+			controller: SELF,
+			game:       &game{numPlayers: 2, players: []*player{SELF: &player{}, OPP: &player{}}},
+			want:       []effectTarget{},
+		},
+		{
+			target:     targetSpell,
+			controller: SELF,
+			game: &game{numPlayers: 2, stack: []stackObject{
+				cardAction{id: 1, card: lavaSpike, action: action{controller: OPP}},
+				abilityOnStack{id: 2, source: prodigalPyromancer, action: action{controller: OPP}},
+				cardAction{id: 3, card: shock, action: action{controller: SELF}},
+			}},
+			want: []effectTarget{{id: 1, ttype: targetSpell}, {id: 3, ttype: targetSpell}},
+		},
+		{
+			target:     targetCreature,
+			controller: SELF,
+			game: &game{numPlayers: 2, players: []*player{
+				SELF: &player{battlefield: battlefield{lands: []cardInstance{{id: 1, card: mountain}}}},
+				OPP:  &player{battlefield: battlefield{creatures: []cardInstance{{id: 2, card: falkenrathReaver}}}},
+			}},
+			want: []effectTarget{{id: 2, ttype: targetCreature}},
+		},
+		{
+			target:     anyTarget,
+			controller: SELF,
+			game: &game{numPlayers: 2, players: []*player{
+				SELF: &player{battlefield: battlefield{lands: []cardInstance{{id: 1, card: mountain}}}},
+				OPP:  &player{battlefield: battlefield{creatures: []cardInstance{{id: 2, card: falkenrathReaver}}}},
+			}},
+			want: []effectTarget{{index: target(SELF), ttype: anyTarget}, {index: target(OPP), ttype: anyTarget}, {id: 2, ttype: anyTarget}},
+		},
+		{
+			target:     anyTarget,
+			controller: SELF,
+			game: &game{numPlayers: 2, players: []*player{
+				SELF: &player{},
+				OPP:  &player{battlefield: battlefield{other: []cardInstance{{id: 3, card: jaceBeleren}, {id: 4, card: bonesplitter}}}},
+			}},
+			want: []effectTarget{{index: target(SELF), ttype: anyTarget}, {index: target(OPP), ttype: anyTarget}, {id: 3, ttype: anyTarget}},
 		},
 	} {
 		got := possibleTargets(tt.game, tt.target, tt.controller)
@@ -50,8 +93,8 @@ func TestGetChild(t *testing.T) {
 			node: node{
 				game: &game{
 					players: []*player{
-						SELF: &player{},
-						OPP:  &player{},
+						SELF: &player{lifeTotal: 20},
+						OPP:  &player{lifeTotal: 20},
 					},
 					priorityPlayer: SELF,
 					activePlayer:   OPP,
@@ -64,8 +107,8 @@ func TestGetChild(t *testing.T) {
 			want: node{
 				game: &game{
 					players: []*player{
-						SELF: &player{},
-						OPP:  &player{},
+						SELF: &player{lifeTotal: 20},
+						OPP:  &player{lifeTotal: 20},
 					},
 					priorityPlayer: OPP,
 					activePlayer:   OPP,
@@ -164,6 +207,41 @@ func TestGetPlayerAction(t *testing.T) {
 				targets: []effectTarget{{index: target(SELF), ttype: targetPlayer}},
 			},
 		},
+		{
+			name: "shock in response for the win",
+			game: &game{
+				players: []*player{
+					SELF: &player{
+						idx: SELF,
+						hand: map[Card]int{
+							shock: 1,
+						},
+						battlefield: testManaAvailable(1),
+						lifeTotal:   3,
+						strategy:    minmaxStrategy{},
+					},
+					OPP: &player{
+						idx:       OPP,
+						lifeTotal: 2,
+						library:   []Card{mountain},
+						strategy:  minmaxStrategy{},
+					},
+				},
+				priorityPlayer: SELF,
+				activePlayer:   OPP,
+				currentStep:    precombatMainPhase,
+				stack: []stackObject{cardAction{
+					card:    lavaSpike,
+					action:  action{controller: OPP},
+					targets: []effectTarget{{index: target(SELF), ttype: targetPlayer}},
+				}},
+			},
+			want: cardAction{
+				card:    shock,
+				action:  action{controller: SELF},
+				targets: []effectTarget{{index: target(OPP), ttype: anyTarget}},
+			},
+		},
 	} {
 		oldMax := maxDepth
 		maxDepth = 5
@@ -222,7 +300,7 @@ func TestGetActions(t *testing.T) {
 			},
 			pointOfView: SELF,
 			want: []Action{
-				cardAction{card: mountain, action: action{controller: SELF}},
+				playLandAction{card: mountain, action: action{controller: SELF}},
 				passAction{action{controller: SELF}},
 			},
 		},
@@ -235,7 +313,7 @@ func TestGetActions(t *testing.T) {
 							mountain:  2,
 							lavaSpike: 3,
 						},
-						battlefield: testManaAvailable(1),
+						battlefield: battlefield{lands: []cardInstance{{id: 1, card: mountain}}},
 						lifeTotal:   20,
 					},
 					OPP: &player{
@@ -259,7 +337,8 @@ func TestGetActions(t *testing.T) {
 					action:  action{controller: SELF},
 					targets: []effectTarget{{index: target(OPP), ttype: targetPlayer}},
 				},
-				cardAction{card: mountain, action: action{controller: SELF}},
+				playLandAction{card: mountain, action: action{controller: SELF}},
+				activateAction{action: action{controller: SELF}, id: 1},
 				passAction{action{controller: SELF}},
 			},
 		},
@@ -272,7 +351,7 @@ func TestGetActions(t *testing.T) {
 							mountain:  2,
 							lavaSpike: 3,
 						},
-						battlefield: testManaAvailable(1),
+						battlefield: battlefield{lands: []cardInstance{{id: 1, card: mountain}}},
 						lifeTotal:   20,
 					},
 					OPP: &player{
@@ -283,10 +362,82 @@ func TestGetActions(t *testing.T) {
 				activePlayer:   SELF,
 				priorityPlayer: SELF,
 				currentStep:    precombatMainPhase,
-				stack:          []cardAction{cardAction{card: lavaSpike, action: action{controller: SELF}}},
+				stack:          []stackObject{cardAction{card: lavaSpike, action: action{controller: SELF}}},
 			},
 			pointOfView: SELF,
-			want:        []Action{passAction{action{controller: SELF}}},
+			want: []Action{
+				activateAction{action: action{controller: SELF}, id: 1},
+				passAction{action{controller: SELF}},
+			},
+		},
+		{
+			name: "card on the stack -> respond with instant",
+			game: &game{
+				players: []*player{
+					SELF: &player{
+						hand: map[Card]int{
+							lavaSpike: 1,
+							shock:     1,
+						},
+						battlefield: battlefield{lands: []cardInstance{{id: 1, card: mountain}}},
+						lifeTotal:   20,
+					},
+					OPP: &player{
+						lifeTotal: 3,
+					},
+				},
+
+				activePlayer:   OPP,
+				priorityPlayer: SELF,
+				currentStep:    precombatMainPhase,
+				stack:          []stackObject{cardAction{card: lavaSpike, action: action{controller: OPP}}},
+			},
+			pointOfView: SELF,
+			want: []Action{
+				cardAction{
+					card:    shock,
+					action:  action{controller: SELF},
+					targets: []effectTarget{{index: target(SELF), ttype: anyTarget}},
+				},
+				cardAction{
+					card:    shock,
+					action:  action{controller: SELF},
+					targets: []effectTarget{{index: target(OPP), ttype: anyTarget}},
+				},
+				activateAction{action: action{controller: SELF}, id: 1},
+				passAction{action{controller: SELF}},
+			},
+		},
+
+		{
+			name: "activated ability",
+			game: &game{
+				players: []*player{
+					SELF: &player{
+						idx: SELF,
+						battlefield: battlefield{creatures: []cardInstance{
+							{id: 1, card: prodigalPyromancer},
+							{id: 2, card: prodigalPyromancer, summoningSickness: true},
+						}},
+						lifeTotal: 20,
+					},
+					OPP: &player{
+						idx:       OPP,
+						lifeTotal: 20,
+					},
+				},
+				activePlayer:   OPP,
+				priorityPlayer: SELF,
+				currentStep:    precombatMainPhase,
+			},
+			pointOfView: SELF,
+			want: []Action{
+				activateAction{action: action{controller: SELF}, id: 1, targets: []effectTarget{{index: target(SELF), ttype: anyTarget}}},
+				activateAction{action: action{controller: SELF}, id: 1, targets: []effectTarget{{index: target(OPP), ttype: anyTarget}}},
+				activateAction{action: action{controller: SELF}, id: 1, targets: []effectTarget{{id: 1, ttype: anyTarget}}},
+				activateAction{action: action{controller: SELF}, id: 1, targets: []effectTarget{{id: 2, ttype: anyTarget}}},
+				passAction{action{controller: SELF}},
+			},
 		},
 
 		// OPPONENT MOVES
@@ -327,7 +478,7 @@ func TestGetActions(t *testing.T) {
 			},
 			pointOfView: SELF,
 			want: []Action{
-				cardAction{card: mountain, action: action{controller: OPP}},
+				playLandAction{card: mountain, action: action{controller: OPP}},
 				passAction{action{controller: OPP}},
 			},
 		},
@@ -342,7 +493,7 @@ func TestGetActions(t *testing.T) {
 							mountain:  2,
 							lavaSpike: 3,
 						},
-						battlefield: testManaAvailable(1),
+						battlefield: battlefield{lands: []cardInstance{{id: 1, card: mountain}}},
 						lifeTotal:   20,
 						deckList:    deckList,
 					},
@@ -364,7 +515,8 @@ func TestGetActions(t *testing.T) {
 					action:  action{controller: OPP},
 					targets: []effectTarget{{index: target(SELF), ttype: targetPlayer}},
 				},
-				cardAction{card: mountain, action: action{controller: OPP}},
+				playLandAction{card: mountain, action: action{controller: OPP}},
+				activateAction{action: action{controller: OPP}, id: 1},
 				passAction{action{controller: OPP}},
 			},
 		},
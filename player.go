@@ -10,15 +10,27 @@ type player struct {
 	idx      int
 	deckList unorderedCards
 
-	lifeTotal   int
+	lifeTotal int
+	// 122.1 players can have counters too, i.e. poison counters
+	counters    map[counterType]int
 	hand        unorderedCards
 	library     orderedCards
 	battlefield battlefield
 	graveyard   orderedCards
-	manaPool    mana
+	exile       orderedCards
+	// 408.1 the command zone, i.e. for commanders
+	command  orderedCards
+	manaPool mana
+
+	// commander variant, see commander.go
+	commander      Card
+	commanderCasts int
+	// commanderDamage: combat damage dealt to this player by commanders, by index of their owner
+	commanderDamage map[int]int
 
 	landPlayed bool
 	decked     bool
+	lost       bool
 
 	strategy Strategy
 }
@@ -43,14 +55,37 @@ func copyBattlefield(list []cardInstance) []cardInstance {
 	}
 	newlist := make([]cardInstance, len(list))
 	for i, ci := range list {
+		ci.counters = copyCounters(ci.counters)
 		newlist[i] = ci
 	}
 	return newlist
 }
 
+func copyCounters(c map[counterType]int) map[counterType]int {
+	if c == nil {
+		return nil
+	}
+	counters := map[counterType]int{}
+	for k, v := range c {
+		counters[k] = v
+	}
+	return counters
+}
+
 func (p *player) copy() *player {
 	newP := &player{}
 	*newP = *p
+	newP.battlefield = p.battlefield.copy()
+	newP.graveyard = p.graveyard.copy()
+	newP.exile = p.exile.copy()
+	newP.command = p.command.copy()
+	newP.counters = copyCounters(p.counters)
+	if p.commanderDamage != nil {
+		newP.commanderDamage = map[int]int{}
+		for k, v := range p.commanderDamage {
+			newP.commanderDamage[k] = v
+		}
+	}
 	if len(p.hand) == 0 {
 		return newP
 	}
@@ -58,63 +93,86 @@ func (p *player) copy() *player {
 	for k, v := range p.hand {
 		newP.hand[k] = v
 	}
-	newP.battlefield = p.battlefield.copy()
 	// TODO: deep copy strategy once you keep state on it
 	return newP
 }
 
-func (p *player) drawN(n int) {
-	for i := 0; i < n; i++ {
-		p.draw()
+// removePermanent takes a permanent off the battlefield,
+// returning false if the player does not control it
+func (p *player) removePermanent(id uint64) (cardInstance, bool) {
+	for _, list := range []*[]cardInstance{&p.battlefield.lands, &p.battlefield.creatures, &p.battlefield.other} {
+		for i, ci := range *list {
+			if ci.id != id {
+				continue
+			}
+			*list = append((*list)[:i:i], (*list)[i+1:]...)
+			if len(*list) == 0 {
+				*list = nil
+			}
+			return ci, true
+		}
 	}
+	return cardInstance{}, false
 }
 
-func (p *player) draw() {
-	if len(p.library) == 0 {
-		p.decked = true
-		return
-	}
-	if p.hand == nil {
-		p.hand = unorderedCards{}
+// permanent returns a pointer into the battlefield, so changes stick.
+// It is only valid until the battlefield changes.
+func (p *player) permanent(id uint64) *cardInstance {
+	for _, list := range [][]cardInstance{p.battlefield.lands, p.battlefield.creatures, p.battlefield.other} {
+		for i := range list {
+			if list[i].id == id {
+				return &list[i]
+			}
+		}
 	}
-	card := p.library[0]
-	p.hand[card] += 1
-	p.library = p.library[1:]
+	return nil
 }
 
-// assumptions: only lands make mana, and only one amount per land!
-func (p *player) manaMap() map[uint64]mana {
-	m := map[uint64]mana{}
+// permanents lists everything the player controls on the battlefield
+func (p *player) permanents() []cardInstance {
+	list := []cardInstance{}
+	list = append(list, p.battlefield.lands...)
+	list = append(list, p.battlefield.creatures...)
+	list = append(list, p.battlefield.other...)
+	return list
+}
+
+// 704.5a-c a player with 0 or less life, who attempted to draw
+// from an empty library, or with ten or more poison counters loses the game
+func (p *player) losesGame() bool {
+	return p.lost || p.lifeTotal <= 0 || p.decked || p.counters[poisonCounter] >= 10 || p.commanderDamageLethal()
+}
+
+// manaSources lists the untapped lands that can be tapped for mana, in battlefield order.
+// assumption: only lands make mana
+func (p *player) manaSources() []manaSource {
+	sources := []manaSource{}
 	for _, l := range p.battlefield.lands {
 		if l.tapped {
 			continue
 		}
-		// TODO: assume land only has one activated ability
-		// and that is a mana ability
-		a := l.card.getActivatedAbilities()[0]
-		if !a.isManaAbility() {
-			panic("broken assumption on land abilities")
+		source := manaSource{id: l.id}
+		for i, a := range l.card.getActivatedAbilities() {
+			if !a.isManaAbility() || a.cost != (cost{tap: true}) {
+				continue
+			}
+			source.options = append(source.options, manaOption{index: i, amount: a.getEffect().(addMana).amount})
+		}
+		if len(source.options) > 0 {
+			sources = append(sources, source)
 		}
-		amount := a.getEffect().(addMana).amount
-		m[l.id] = amount
-	}
-	return m
-}
-
-func (p *player) manaAvailable() mana {
-	sum := mana{}
-	for _, v := range p.manaMap() {
-		sum = sum.add(v)
 	}
-	return sum
+	return sources
 }
 
+// hasMana uses the same solver as paying for costs, so that 'can pay' and 'did pay' never disagree
 func (p *player) hasMana(m mana) bool {
-	return p.manaAvailable().covers(m)
+	_, ok := solveManaPayment(p.manaPool, p.manaSources(), m)
+	return ok
 }
 
 func (p *player) String() string {
-	return fmt.Sprintf("life: %d, mana: %d/%d, hand: %s", p.lifeTotal, p.manaAvailable(), len(p.battlefield.lands), p.hand.String())
+	return fmt.Sprintf("life: %d, mana: %d/%d, pool: %d, hand: %s", p.lifeTotal, len(p.manaSources()), len(p.battlefield.lands), p.manaPool, p.hand.String())
 }
 
 func newPlayer(idx int, name string, deckList unorderedCards) *player {
@@ -124,27 +182,49 @@ func newPlayer(idx int, name string, deckList unorderedCards) *player {
 			list = append(list, k)
 		}
 	}
-	shuffled := make(orderedCards, len(list))
-	for i, v := range rand.Perm(len(list)) {
-		shuffled[v] = list[i]
-	}
-	return &player{
+	p := &player{
 		name:      name,
 		idx:       idx,
 		lifeTotal: 20,
-		library:   shuffled,
+		library:   list,
 		hand:      unorderedCards{},
 		deckList:  deckList,
 	}
+	p.shuffle()
+	return p
 }
 
-func (p *player) creaturesThatCanAttack() []int {
-	creatures := []int{}
-	for i, c := range p.battlefield.creatures {
-		if c.tapped || c.summoningSickness {
+// 701.20a To shuffle a library [...] is to randomize the cards within it
+func (p *player) shuffle() {
+	shuffled := make(orderedCards, len(p.library))
+	for i, v := range rand.Perm(len(p.library)) {
+		shuffled[v] = p.library[i]
+	}
+	p.library = shuffled
+}
+
+func (p *player) creaturesThatCanAttack(g *game) []uint64 {
+	creatures := []uint64{}
+	for _, c := range p.battlefield.creatures {
+		// 702.10b haste ignores summoning sickness
+		if c.tapped || (c.summoningSickness && !g.hasKeyword(c, haste)) {
+			continue
+		}
+		if g.characteristics(c).cantAttack {
 			continue
 		}
-		creatures = append(creatures, i)
+		creatures = append(creatures, c.id)
+	}
+	return creatures
+}
+
+func (p *player) creaturesThatCanBlock(g *game) []uint64 {
+	creatures := []uint64{}
+	for _, c := range p.battlefield.creatures {
+		if c.tapped || g.characteristics(c).cantBlock {
+			continue
+		}
+		creatures = append(creatures, c.id)
 	}
 	return creatures
 }
@@ -152,13 +232,17 @@ func (p *player) creaturesThatCanAttack() []int {
 // this means we only check prereqs against what we know
 // may have to change that to a probability prereq is met
 func (p *player) canPlayCard(g *game, card Card) bool {
+	return p.canCast(g, card, g.manaCost(p.idx, card))
+}
+
+func (p *player) canCast(g *game, card Card, cost mana) bool {
 	// prerequisites given by card type
 	if !card.prereq(g, p.idx) {
 		return false
 	}
 
 	// can player pay for the card?
-	if !p.hasMana(card.getManaCost()) {
+	if !p.hasMana(cost) {
 		return false
 	}
 	// other prerequisites such as paying life
@@ -171,3 +255,90 @@ func (p *player) canPlayCard(g *game, card Card) bool {
 	}
 	return true
 }
+
+// canActivate checks whether the player can activate a non-mana ability.
+// Mana abilities are activated while paying costs, see PayManaCost,
+// or explicitly while holding priority, see canActivateManaAbility.
+func (p *player) canActivate(g *game, c cardInstance, index int) bool {
+	aa := c.card.getActivatedAbilities()[index]
+	if aa.isManaAbility() {
+		return false
+	}
+	if !instantSpeed(g, p.idx) {
+		return false
+	}
+	if aa.sorcerySpeed && !sorcerySpeed(g, p.idx) {
+		return false
+	}
+	// 606.3 A player may activate a loyalty ability of a permanent they control any time
+	// they have priority and the stack is empty during a main phase of their turn, but only
+	// if no player has previously activated a loyalty ability of that permanent that turn.
+	if aa.cost.loyaltyAbility {
+		if !sorcerySpeed(g, p.idx) || c.loyaltyActivated {
+			return false
+		}
+		if c.counters[loyaltyCounter]+aa.cost.loyalty < 0 {
+			return false
+		}
+	}
+	if aa.cost.tap {
+		if c.tapped {
+			return false
+		}
+		// 302.6 A creature's activated ability with the tap symbol in its activation cost
+		// can't be activated unless the creature has been under its controller's control
+		// continuously since their most recent turn began.
+		if _, ok := c.card.(*creature); ok && c.summoningSickness && !g.hasKeyword(c, haste) {
+			return false
+		}
+	}
+	// 119.4 a player can pay life only if their life total is greater than or equal to the payment
+	if aa.cost.life > p.lifeTotal {
+		return false
+	}
+	return p.hasMana(aa.cost.mana)
+}
+
+// 605.3a A player may activate an activated mana ability whenever they have priority,
+// or whenever they are asked to pay a cost that includes a mana payment.
+// This checks the former; while paying costs they are activated by PayManaCost.
+func (p *player) canActivateManaAbility(g *game, c cardInstance, index int) bool {
+	aa := c.card.getActivatedAbilities()[index]
+	if !aa.isManaAbility() || !instantSpeed(g, p.idx) {
+		return false
+	}
+	if aa.cost.tap && c.tapped {
+		return false
+	}
+	return p.hasMana(aa.cost.mana)
+}
+
+// removeTokens removes tokens from all zones but the battlefield,
+// returning whether any were found
+func (p *player) removeTokens() bool {
+	found := false
+	for c := range p.hand {
+		if c.isToken() {
+			delete(p.hand, c)
+			found = true
+		}
+	}
+	for _, zone := range []*orderedCards{&p.library, &p.graveyard, &p.exile} {
+		var cards orderedCards
+		for _, c := range *zone {
+			if c.isToken() {
+				found = true
+				continue
+			}
+			cards = append(cards, c)
+		}
+		if len(cards) != len(*zone) {
+			*zone = cards
+		}
+	}
+	return found
+}
+
+func (p *player) addCounters(ct counterType, n int) {
+	p.counters = addCounters(p.counters, ct, n)
+}
@@ -0,0 +1,242 @@
+package main
+
+// 614.1 Some continuous effects are replacement effects. Like prevention effects (see rule 615),
+// replacement effects apply continuously as events happen—they aren't locked in ahead of time.
+// Such effects watch for a particular event that would happen and completely or partially
+// replace that event with a different event.
+// Events that can be replaced are damage being dealt, cards being drawn,
+// creatures dying and players gaining life; see replace.
+
+type replacement struct {
+	event eventType
+	// you: only events happening to the controller, i.e. 'if you would draw a card'
+	you bool
+	// combat: only combat damage, i.e. 'prevent all combat damage'
+	combat bool
+	// emptyLibrary: only while the affected player's library is empty
+	emptyLibrary bool
+	// 615.1a prevent up to this much damage, -1 to prevent all of it.
+	// a prevention shield from a resolved spell or ability is used up as it prevents damage
+	prevent int
+	// 'you gain twice that much life instead'
+	double bool
+	// 'exile it instead'
+	exile bool
+	// skip the event and apply this effect to the controller instead
+	instead Effect
+	// 'the next time': the effect is used up after replacing one event
+	once bool
+}
+
+func (r replacement) matches(g *game, e event, controller int) bool {
+	if !e.is(r.event) {
+		return false
+	}
+	if r.you && e.player != controller {
+		return false
+	}
+	if r.combat && !e.combat {
+		return false
+	}
+	if r.emptyLibrary && len(g.getPlayer(e.player).library) != 0 {
+		return false
+	}
+	return true
+}
+
+// a replacement effect created by a resolving spell or ability,
+// as opposed to one generated by a static ability
+type activeReplacement struct {
+	replacement replacement
+	controller  int
+	// if set, only events happening to these permanents are replaced
+	ids      []uint64
+	duration duration
+	used     bool
+}
+
+// identifies a replacement effect so it applies to an event only once:
+// either a static ability of a permanent or an active replacement effect
+type replacementKey struct {
+	source uint64
+	n      int
+	active int
+}
+
+type replacementOption struct {
+	key         replacementKey
+	replacement replacement
+	controller  int
+}
+
+func (g *game) applicableReplacements(e event, applied map[replacementKey]struct{}) []replacementOption {
+	options := []replacementOption{}
+	for i, p := range g.players {
+		for _, source := range p.permanents() {
+			for n, sa := range source.card.getStaticAbilities() {
+				if sa.replacement == nil || !sa.replacement.matches(g, e, i) {
+					continue
+				}
+				key := replacementKey{source: source.id, n: n, active: -1}
+				if _, ok := applied[key]; ok {
+					continue
+				}
+				options = append(options, replacementOption{key: key, replacement: *sa.replacement, controller: i})
+			}
+		}
+	}
+	for n, ar := range g.replacementEffects {
+		if ar.used || !ar.replacement.matches(g, e, ar.controller) {
+			continue
+		}
+		if ar.ids != nil && !containsID(ar.ids, e.id) {
+			continue
+		}
+		key := replacementKey{active: n}
+		if _, ok := applied[key]; ok {
+			continue
+		}
+		options = append(options, replacementOption{key: key, replacement: ar.replacement, controller: ar.controller})
+	}
+	return options
+}
+
+func containsID(ids []uint64, id uint64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// replace returns the event as modified by replacement and prevention effects,
+// and whether it still happens at all.
+// 616.1 If two or more replacement and/or prevention effects are attempting to modify the way
+// an event affects an object or player, the affected object's controller [...] or the affected
+// player chooses one to apply. 616.1f Once the chosen effect has been applied, this process is
+// repeated (taking into account only replacement or prevention effects that would now be applicable).
+// 614.5 A replacement effect doesn't invoke itself repeatedly; it gets only one opportunity to affect an event.
+func (g *game) replace(e event) (event, bool) {
+	applied := map[replacementKey]struct{}{}
+	for {
+		options := g.applicableReplacements(e, applied)
+		if len(options) == 0 {
+			return e, true
+		}
+		choice := 0
+		if len(options) > 1 {
+			p := g.getPlayer(e.player)
+			rs := make([]replacement, len(options))
+			for i, o := range options {
+				rs[i] = o.replacement
+			}
+			choice = p.strategy.ChooseReplacement(p, g, rs)
+		}
+		o := options[choice]
+		applied[o.key] = struct{}{}
+		var happens bool
+		e, happens = g.applyReplacement(o, e)
+		if !happens {
+			return e, false
+		}
+	}
+}
+
+func (g *game) applyReplacement(o replacementOption, e event) (event, bool) {
+	r := o.replacement
+	if o.key.active != -1 && r.once {
+		g.replacementEffects[o.key.active].used = true
+	}
+	switch {
+	case r.prevent == -1:
+		// 615.1a [...] prevents damage from being dealt
+		return e, false
+	case r.prevent > 0:
+		prevented := r.prevent
+		if prevented > e.amount {
+			prevented = e.amount
+		}
+		e.amount -= prevented
+		if o.key.active != -1 {
+			ar := &g.replacementEffects[o.key.active]
+			ar.replacement.prevent -= prevented
+			if ar.replacement.prevent == 0 {
+				ar.used = true
+			}
+		}
+		return e, e.amount > 0
+	case r.double:
+		e.amount *= 2
+	case r.exile:
+		e.to = zoneExile
+	case r.instead != nil:
+		r.instead.apply(g, []effectTarget{{index: target(o.controller), ttype: you}})
+		return e, false
+	}
+	return e, true
+}
+
+// see endUntilEndOfTurnEffects; replacement effects that were used up are removed as well
+func (g *game) endUntilEndOfTurnReplacements() {
+	var effects []activeReplacement
+	for _, ar := range g.replacementEffects {
+		if ar.used || ar.duration == untilEndOfTurn {
+			continue
+		}
+		effects = append(effects, ar)
+	}
+	g.replacementEffects = effects
+}
+
+// addReplacement starts a replacement effect from a resolving spell or ability
+func (g *game) addReplacement(r replacement, controller int, ids []uint64, d duration) {
+	g.replacementEffects = append(g.replacementEffects, activeReplacement{
+		replacement: r,
+		controller:  controller,
+		ids:         ids,
+		duration:    d,
+	})
+}
+
+// dealDamage deals the damage described by e to the permanent with id e.id,
+// or to player e.player if it is not set, and returns the damage actually dealt
+func (g *game) dealDamage(e event) int {
+	e, ok := g.replace(e)
+	if !ok || e.amount <= 0 {
+		return 0
+	}
+	if e.id != 0 {
+		c, _ := g.findPermanent(e.id)
+		switch {
+		case isPlaneswalker(c.card):
+			// 120.3c Damage dealt to a planeswalker causes that many loyalty counters to be removed from that planeswalker.
+			c.addCounters(loyaltyCounter, -e.amount)
+		case e.infect:
+			// 120.3d Damage dealt to a creature by a source with wither and/or infect causes that many -1/-1 counters to be put on that creature.
+			c.addCounters(minusOneCounter, e.amount)
+		default:
+			// 120.3e Damage dealt to a creature causes that much damage to be marked on it.
+			c.damage += e.amount
+		}
+	} else {
+		p := g.getPlayer(e.player)
+		if e.infect {
+			// 120.3b Damage dealt to a player by a source with infect causes that player to get that many poison counters.
+			p.addCounters(poisonCounter, e.amount)
+		} else {
+			p.lifeTotal -= e.amount
+		}
+	}
+	g.emit(e)
+	return e.amount
+}
+
+func (g *game) gainLife(i, amount int) {
+	e, ok := g.replace(event{etype: lifeGained, player: i, amount: amount})
+	if !ok {
+		return
+	}
+	g.getPlayer(i).lifeTotal += e.amount
+	g.emit(e)
+}
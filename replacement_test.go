@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestReplaceDamage(t *testing.T) {
+	double := activeReplacement{replacement: replacement{event: damageDealt, double: true}, controller: SELF}
+	shield := activeReplacement{replacement: replacement{event: damageDealt, prevent: 2}, controller: SELF}
+	for i, tt := range []struct {
+		name         string
+		strategy     Strategy
+		replacements []activeReplacement
+		combat       bool
+		want         int
+		wantLeft     int
+	}{
+		{
+			name: "no replacement",
+			want: 17,
+		},
+		{
+			name:         "fog prevents combat damage",
+			replacements: []activeReplacement{{replacement: replacement{event: damageDealt, combat: true, prevent: -1}}},
+			combat:       true,
+			want:         20,
+			wantLeft:     1,
+		},
+		{
+			name:         "fog does not prevent noncombat damage",
+			replacements: []activeReplacement{{replacement: replacement{event: damageDealt, combat: true, prevent: -1}}},
+			want:         17,
+			wantLeft:     1,
+		},
+		{
+			name:         "prevention shield is used up",
+			replacements: []activeReplacement{shield},
+			want:         19,
+		},
+		{
+			name:         "affected player chooses to prevent first",
+			strategy:     simpleStrategy{},
+			replacements: []activeReplacement{double, shield},
+			want:         18,
+			wantLeft:     1,
+		},
+		{
+			name:         "affected player chooses to double first",
+			strategy:     goldfish{},
+			replacements: []activeReplacement{double, shield},
+			want:         16,
+			wantLeft:     1,
+		},
+	} {
+		g := &game{
+			players:            []*player{SELF: &player{lifeTotal: 20, strategy: tt.strategy}, OPP: &player{lifeTotal: 20}},
+			numPlayers:         2,
+			replacementEffects: tt.replacements,
+		}
+		g.dealDamage(event{etype: damageDealt, player: SELF, amount: 3, combat: tt.combat})
+		if got := g.getPlayer(SELF).lifeTotal; got != tt.want {
+			t.Errorf("%d: %s) life total: got %d want %d", i, tt.name, got, tt.want)
+		}
+		got := 0
+		for _, ar := range g.replacementEffects {
+			if !ar.used {
+				got++
+			}
+		}
+		if got != tt.wantLeft {
+			t.Errorf("%d: %s) replacement effects left: got %d want %d", i, tt.name, got, tt.wantLeft)
+		}
+	}
+}
+
+func TestExileInsteadOfDying(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{},
+			OPP:  &player{battlefield: battlefield{creatures: []cardInstance{{id: 1, card: falkenrathReaver, owner: OPP}}}},
+		},
+		numPlayers: 2,
+	}
+	magmaSpray.getSpellAbility().getEffect().apply(g, []effectTarget{{id: 1, ttype: targetCreature}})
+	g.checkStateBasedActions()
+	opp := g.getPlayer(OPP)
+	if len(opp.battlefield.creatures) != 0 {
+		t.Fatalf("creature should have left the battlefield")
+	}
+	if len(opp.graveyard) != 0 || len(opp.exile) != 1 {
+		t.Errorf("creature should be exiled instead of dying: graveyard %v exile %v", opp.graveyard, opp.exile)
+	}
+}
+
+func TestReplaceDraw(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{{id: 1, card: laboratoryManiac}}}},
+			OPP:  &player{lifeTotal: 20},
+		},
+		numPlayers: 2,
+	}
+	g.drawCards(SELF, 1)
+	if g.getPlayer(SELF).losesGame() {
+		t.Errorf("replaced draw from an empty library should not lose the game")
+	}
+	if !g.getPlayer(OPP).losesGame() {
+		t.Errorf("opponent should lose the game")
+	}
+	g.drawCards(OPP, 1)
+	if !g.getPlayer(OPP).decked {
+		t.Errorf("opponent should have drawn from an empty library")
+	}
+}
+
+func TestReplaceLifeGain(t *testing.T) {
+	g := &game{
+		players: []*player{
+			SELF: &player{lifeTotal: 10, battlefield: battlefield{creatures: []cardInstance{{id: 1, card: rhoxFaithmender}}}},
+			OPP:  &player{lifeTotal: 10},
+		},
+		numPlayers: 2,
+	}
+	lifegain{3}.apply(g, []effectTarget{{ttype: eachPlayer}})
+	if got := g.getPlayer(SELF).lifeTotal; got != 16 {
+		t.Errorf("life total: got %d want %d", got, 16)
+	}
+	if got := g.getPlayer(OPP).lifeTotal; got != 13 {
+		t.Errorf("opponent life total: got %d want %d", got, 13)
+	}
+}
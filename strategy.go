@@ -1,13 +1,27 @@
 package main
 
+import "sort"
+
 // a player has a strategy they follow, their AI (or human-controlled) behaviour
 
 type Strategy interface {
 	NextAction(*player, *game) Action
 	Attacks(*player, *game) attackAction
-	// TODO: change to return an action; validation of actions
-	// should not happen inside of strategy!
-	PayManaCost(p *player, cost mana)
+	Blocks(*player, *game) blockAction
+	ChooseTargets(p *player, g *game, options [][]effectTarget) []effectTarget
+	// 616.1 returns the index of the replacement effect to apply first
+	ChooseReplacement(p *player, g *game, options []replacement) int
+	// 704.5j returns the id of the one legendary permanent to keep of several with the same name
+	ChooseLegend(p *player, g *game, legends []cardInstance) uint64
+	// 514.1 returns n cards from hand to discard
+	Discard(p *player, g *game, n int) []Card
+	// 103.5 returns whether to take another mulligan
+	Mulligan(hand unorderedCards, mulligansTaken int) bool
+	// 103.5 returns n cards from the opening hand to put on the bottom of the library
+	ChooseBottom(p *player, g *game, n int) []Card
+	// 601.2g activates mana abilities until the mana pool covers the cost,
+	// which is then paid from the pool by the game
+	PayManaCost(p *player, g *game, cost mana)
 }
 
 // your goldfish can't play magic, so it always just passes
@@ -21,20 +35,80 @@ func (goldfish) Attacks(p *player, g *game) attackAction {
 	return attackAction{action: action{controller: p.idx}, attackers: nil}
 }
 
-func (goldfish) PayManaCost(p *player, cost mana) {
-	payNaive(p, cost)
+func (goldfish) Blocks(p *player, g *game) blockAction {
+	return blockAction{action: action{controller: p.idx}, blockers: nil}
+}
+
+func (goldfish) ChooseTargets(p *player, g *game, options [][]effectTarget) []effectTarget {
+	return options[0]
+}
+
+func (goldfish) ChooseReplacement(p *player, g *game, options []replacement) int {
+	return 0
+}
+
+func (goldfish) ChooseLegend(p *player, g *game, legends []cardInstance) uint64 {
+	return keepNewestLegend(legends)
+}
+
+func (goldfish) Discard(p *player, g *game, n int) []Card {
+	return discardHighestCost(p, g, n)
+}
+
+func (goldfish) Mulligan(hand unorderedCards, mulligansTaken int) bool {
+	return false
+}
+
+func (goldfish) ChooseBottom(p *player, g *game, n int) []Card {
+	return bottomKeepingBalance(p, g, n)
+}
+
+func (goldfish) PayManaCost(p *player, g *game, cost mana) {
+	payOptimal(p, g, cost)
 }
 
 // TODO: a simpler strategy hardcoding the simple deck we have
 // never do anything first main phase.
 // always attack with everything, never block
 // second main phase, always play a land first
-// always play a creature if you can
+// always play a creature if you can, your commander first
 // otherwise, always play lava spike face
+// and use abilities that can hit the opponent in the face
+// whenever shock would be lethal, play it, even in response
+// always counter the top of the stack if it is the opponent's spell
 // pass in every other step ever
 type simpleStrategy struct{}
 
 func (simpleStrategy) NextAction(p *player, g *game) Action {
+	opp := weakestOpponent(g, p.idx)
+	for c := range p.hand {
+		if c.getName() != "Shock" {
+			continue
+		}
+		if !p.canPlayCard(g, c) {
+			continue
+		}
+		if g.getPlayer(opp).lifeTotal > 2 {
+			continue
+		}
+		return cardAction{card: c, action: action{controller: p.idx}, targets: []effectTarget{{index: target(opp), ttype: anyTarget}}}
+	}
+	for c := range p.hand {
+		if c.getName() != "Counterspell" {
+			continue
+		}
+		if !p.canPlayCard(g, c) {
+			continue
+		}
+		if len(g.stack) == 0 {
+			continue
+		}
+		top := g.stack[len(g.stack)-1]
+		if !isSpell(top) || top.getController() == p.idx {
+			continue
+		}
+		return cardAction{card: c, action: action{controller: p.idx}, targets: []effectTarget{{id: top.getID(), ttype: targetSpell}}}
+	}
 	if g.currentStep != postcombatMainPhase {
 		return passAction{action{controller: p.idx}}
 	}
@@ -45,7 +119,7 @@ func (simpleStrategy) NextAction(p *player, g *game) Action {
 		if !p.canPlayCard(g, c) {
 			continue
 		}
-		return cardAction{card: c, action: action{controller: p.idx}}
+		return playLandAction{card: c, action: action{controller: p.idx}}
 	}
 	for c := range p.hand {
 		if c.getName() != "Island" {
@@ -54,7 +128,10 @@ func (simpleStrategy) NextAction(p *player, g *game) Action {
 		if !p.canPlayCard(g, c) {
 			continue
 		}
-		return cardAction{card: c, action: action{controller: p.idx}}
+		return playLandAction{card: c, action: action{controller: p.idx}}
+	}
+	if p.canCastCommander(g) {
+		return p.castCommander()
 	}
 	for c := range p.hand {
 		if _, ok := c.(*creature); !ok {
@@ -72,7 +149,7 @@ func (simpleStrategy) NextAction(p *player, g *game) Action {
 		if !p.canPlayCard(g, c) {
 			continue
 		}
-		return cardAction{card: c, action: action{controller: p.idx}, targets: []effectTarget{{index: target((p.idx + 1) % 2), ttype: targetPlayer}}}
+		return cardAction{card: c, action: action{controller: p.idx}, targets: []effectTarget{{index: target(opp), ttype: targetPlayer}}}
 	}
 	for c := range p.hand {
 		if c.getName() != "Flame Rift" {
@@ -81,7 +158,7 @@ func (simpleStrategy) NextAction(p *player, g *game) Action {
 		if !p.canPlayCard(g, c) {
 			continue
 		}
-		if p.lifeTotal <= 4 || p.lifeTotal < g.getOpponent(p.idx).lifeTotal {
+		if p.lifeTotal <= 4 || p.lifeTotal < g.getPlayer(opp).lifeTotal {
 			continue
 		}
 		return cardAction{card: c, action: action{controller: p.idx}, targets: []effectTarget{{ttype: eachPlayer}}}
@@ -93,62 +170,190 @@ func (simpleStrategy) NextAction(p *player, g *game) Action {
 		if !p.canPlayCard(g, c) {
 			continue
 		}
-		return cardAction{card: c, action: action{controller: p.idx}, targets: []effectTarget{{ttype: you}}}
+		return cardAction{card: c, action: action{controller: p.idx}, targets: []effectTarget{{index: target(p.idx), ttype: you}}}
+	}
+	for _, c := range p.permanents() {
+		for i, aa := range c.card.getActivatedAbilities() {
+			if !p.canActivate(g, c, i) || aa.cost.sacrifice {
+				continue
+			}
+			if len(aa.targets) != 1 || (aa.targets[0] != anyTarget && aa.targets[0] != targetPlayer) {
+				continue
+			}
+			return activateAction{action: action{controller: p.idx}, id: c.id, index: i, targets: []effectTarget{{index: target(opp), ttype: aa.targets[0]}}}
+		}
 	}
 	return passAction{action{controller: p.idx}}
 }
 
 func (simpleStrategy) Attacks(p *player, g *game) attackAction {
-	return attackWithAll(p, p.idx)
+	return attackWithAll(g, p, p.idx)
+}
+
+func (simpleStrategy) Blocks(p *player, g *game) blockAction {
+	return blockAction{action: action{controller: p.idx}, blockers: nil}
+}
+
+func (simpleStrategy) ChooseTargets(p *player, g *game, options [][]effectTarget) []effectTarget {
+	return chooseHostileTargets(p, g, options)
+}
+
+func (simpleStrategy) ChooseReplacement(p *player, g *game, options []replacement) int {
+	return choosePrevention(options)
+}
+
+func (simpleStrategy) ChooseLegend(p *player, g *game, legends []cardInstance) uint64 {
+	return keepNewestLegend(legends)
+}
+
+func (simpleStrategy) Discard(p *player, g *game, n int) []Card {
+	return discardHighestCost(p, g, n)
+}
+
+func (simpleStrategy) Mulligan(hand unorderedCards, mulligansTaken int) bool {
+	return mulliganOnLandCount(hand, mulligansTaken)
 }
 
-func (simpleStrategy) PayManaCost(p *player, cost mana) {
-	payNaive(p, cost)
+func (simpleStrategy) ChooseBottom(p *player, g *game, n int) []Card {
+	return bottomKeepingBalance(p, g, n)
 }
 
-func attackWithAll(p *player, index int) attackAction {
-	creatures := p.creaturesThatCanAttack()
-	// two player assumption
-	opp := (index + 1) % 2
+func (simpleStrategy) PayManaCost(p *player, g *game, cost mana) {
+	payOptimal(p, g, cost)
+}
+
+// attackWithAll attacks the weakest opponent with everything
+func attackWithAll(g *game, p *player, index int) attackAction {
+	return attackWithAllAt(g, p, index, weakestOpponent(g, index))
+}
+
+func attackWithAllAt(g *game, p *player, index, opp int) attackAction {
+	creatures := p.creaturesThatCanAttack(g)
 	attackers := []combatTarget{}
 	for _, c := range creatures {
-		attackers = append(attackers, combatTarget{index: c, target: opp})
+		attackers = append(attackers, combatTarget{id: c, target: opp})
 	}
 	return attackAction{action: action{controller: index}, attackers: attackers}
 }
 
-// assumption: player has the mana to pay
-func payNaive(p *player, cost mana) {
-	available := p.manaMap()
-	// TODO: find/pay colored mana first, then spend rest to pay colorless
-	toTap := map[uint64]struct{}{}
-	for id, m := range available {
-		if cost.converted() == 0 {
-			break
+// weakestOpponent is the opponent with the lowest life total, the first in turn order on ties
+func weakestOpponent(g *game, index int) int {
+	opps := g.getOpponents(index)
+	weakest := opps[0]
+	for _, i := range opps[1:] {
+		if g.getPlayer(i).lifeTotal < g.getPlayer(weakest).lifeTotal {
+			weakest = i
+		}
+	}
+	return weakest
+}
+
+// chooseHostileTargets prefers targets the player does not control
+// TODO: not every effect is bad for its target
+func chooseHostileTargets(p *player, g *game, options [][]effectTarget) []effectTarget {
+	for _, option := range options {
+		hostile := true
+		for _, t := range option {
+			if t.isPermanent() {
+				if _, controller := g.findPermanent(t.id); controller == p.idx {
+					hostile = false
+				}
+				continue
+			}
+			if t.ttype == targetSpell {
+				if i := g.findOnStack(t.id); i != -1 && g.stack[i].getController() == p.idx {
+					hostile = false
+				}
+				continue
+			}
+			if !t.ttype.isUntargeted() && int(t.index) == p.idx {
+				hostile = false
+			}
 		}
-		toTap[id] = struct{}{}
-		switch {
-		case cost.w > 0 && m.w > 0:
-			cost = cost.sub(m)
-		case cost.u > 0 && m.u > 0:
-			cost = cost.sub(m)
-		case cost.b > 0 && m.b > 0:
-			cost = cost.sub(m)
-		case cost.r > 0 && m.r > 0:
-			cost = cost.sub(m)
-		case cost.g > 0 && m.g > 0:
-			cost = cost.sub(m)
-		default:
-			cost.c -= m.converted()
+		if hostile {
+			return option
 		}
 	}
+	return options[0]
+}
 
-	// actually tap the lands --> should be done outside of strategy!
-	for i, l := range p.battlefield.lands {
-		if _, ok := toTap[l.id]; !ok {
+// choosePrevention applies prevention effects first, so that damage is reduced
+// before anything else can modify it
+func choosePrevention(options []replacement) int {
+	for i, r := range options {
+		if r.prevent != 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// keepNewestLegend keeps the legendary permanent that entered the battlefield last,
+// the latest in battlefield order on ties
+func keepNewestLegend(legends []cardInstance) uint64 {
+	newest := legends[0]
+	for _, c := range legends[1:] {
+		if c.timestamp >= newest.timestamp {
+			newest = c
+		}
+	}
+	return newest.id
+}
+
+// discardHighestCost discards the most expensive cards first, keeping lands,
+// since those are the hardest to cast. ties are broken by name to stay deterministic.
+func discardHighestCost(p *player, g *game, n int) []Card {
+	cards := []Card{}
+	for c, v := range p.hand {
+		for i := 0; i < v; i++ {
+			cards = append(cards, c)
+		}
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		ci, cj := g.manaCost(p.idx, cards[i]).converted(), g.manaCost(p.idx, cards[j]).converted()
+		if ci != cj {
+			return ci > cj
+		}
+		return cards[i].getName() < cards[j].getName()
+	})
+	return cards[:n]
+}
+
+// mulliganOnLandCount mulligans seven card hands with fewer than two or more than five lands,
+// and keeps any hand once it would go down to five cards
+func mulliganOnLandCount(hand unorderedCards, mulligansTaken int) bool {
+	if mulligansTaken >= 2 {
+		return false
+	}
+	lands := 0
+	for c, v := range hand {
+		if _, ok := c.(*land); ok {
+			lands += v
+		}
+	}
+	return lands < 2 || lands > 5
+}
+
+// bottomKeepingBalance puts lands on the bottom while more than half of the hand is lands,
+// and the most expensive cards otherwise
+func bottomKeepingBalance(p *player, g *game, n int) []Card {
+	var lands, spells []Card
+	for _, c := range discardHighestCost(p, g, p.hand.size()) {
+		if _, ok := c.(*land); ok {
+			lands = append(lands, c)
+			continue
+		}
+		spells = append(spells, c)
+	}
+	bottom := []Card{}
+	for len(bottom) < n {
+		if len(spells) == 0 || 2*len(lands) > len(lands)+len(spells) {
+			bottom = append(bottom, lands[0])
+			lands = lands[1:]
 			continue
 		}
-		l.tapped = true
-		p.battlefield.lands[i] = l
+		bottom = append(bottom, spells[0])
+		spells = spells[1:]
 	}
+	return bottom
 }
@@ -0,0 +1,181 @@
+package main
+
+// 400.1 A zone is a place where objects can be during a game. There are normally seven zones:
+// library, hand, battlefield, graveyard, stack, exile, and command.
+type zone int
+
+const (
+	zoneLibrary zone = iota
+	zoneHand
+	zoneBattlefield
+	zoneGraveyard
+	zoneStack
+	zoneExile
+	zoneCommand
+	// 111.1 tokens are created on the battlefield rather than moved from another zone
+	zoneNone
+)
+
+// moveCard is the single routine moving cards and tokens between zones.
+// c.owner decides whose zones the card moves between, and c.id which permanent leaves the battlefield.
+// The stack is kept by the game, so cards moving from the stack should already have been taken off it.
+// Zone changes can be replaced, i.e. 'exile it instead', and are emitted as events.
+// If the card ends up on the battlefield, the new permanent is returned.
+func (g *game) moveCard(c cardInstance, from, to zone) cardInstance {
+	return g.moveCardTo(c, from, to, top)
+}
+
+// position in the library a card is put into
+type position int
+
+const (
+	top position = iota
+	bottom
+)
+
+// moveCardTo is moveCard for effects that say where in the library the card goes,
+// i.e. 'put that card on the bottom of its owner's library'
+func (g *game) moveCardTo(c cardInstance, from, to zone, pos position) cardInstance {
+	controller := c.owner
+	if from == zoneBattlefield {
+		c, controller = g.removePermanent(c.id)
+	} else {
+		g.getPlayer(c.owner).removeFromZone(c.card, from)
+	}
+	e, _ := g.replace(event{etype: zoneChange, player: controller, id: c.id, card: c.card, from: from, to: to})
+	if g.isCommander(c) {
+		e.to = commanderZone(e.to)
+	}
+	if e.to == zoneBattlefield {
+		return g.enterBattlefield(c, c.owner, from)
+	}
+	g.getPlayer(c.owner).addToZone(c.card, e.to, pos)
+	g.emit(e)
+	return cardInstance{}
+}
+
+// enterBattlefield puts a card onto the battlefield under the control of controller.
+// 400.7 An object that moves from one zone to another becomes a new object with no memory of
+// or relation to its previous existence: it gets a new id, and only keeps its owner and
+// what an aura entering the battlefield will be attached to.
+func (g *game) enterBattlefield(c cardInstance, controller int, from zone) cardInstance {
+	p := g.getPlayer(controller)
+	instance := instanceOf(c.card)
+	instance.owner = c.owner
+	instance.attachedTo = c.attachedTo
+	// 613.7d A permanent receives a timestamp at the time it entered the battlefield.
+	instance.timestamp = g.nextTimestamp()
+	switch card := c.card.(type) {
+	case *land:
+		p.battlefield.lands = append(p.battlefield.lands, instance)
+	case *creature:
+		instance.attacking = -1
+		instance.summoningSickness = true
+		p.battlefield.creatures = append(p.battlefield.creatures, instance)
+	case *planeswalker:
+		// 306.5b A planeswalker has the intrinsic ability "This permanent enters the battlefield
+		// with a number of loyalty counters on it equal to its printed loyalty number."
+		instance.counters = map[counterType]int{loyaltyCounter: card.loyalty}
+		p.battlefield.other = append(p.battlefield.other, instance)
+	default:
+		p.battlefield.other = append(p.battlefield.other, instance)
+	}
+	g.emit(event{etype: zoneChange, player: controller, id: instance.id, card: c.card, from: from, to: zoneBattlefield})
+	return instance
+}
+
+// 121.1 A player draws a card by putting the top card of their library into their hand.
+// drawCards is the single routine for drawing cards, including the opening hand
+func (g *game) drawCards(i, n int) {
+	p := g.getPlayer(i)
+	for j := 0; j < n; j++ {
+		// 121.6 Some effects replace card draws. A replaced draw from an empty library doesn't lose the game.
+		if _, ok := g.replace(event{etype: cardDrawn, player: i}); !ok {
+			continue
+		}
+		// 704.5b a player who attempted to draw from an empty library loses the game
+		if len(p.library) == 0 {
+			p.decked = true
+			continue
+		}
+		card := p.library[0]
+		g.moveCard(cardInstance{card: card, owner: i}, zoneLibrary, zoneHand)
+		g.emit(event{etype: cardDrawn, player: i, card: card})
+	}
+}
+
+// 103.5 a player who takes a mulligan shuffles their hand into their library
+func (g *game) shuffleHandIntoLibrary(i int) {
+	p := g.getPlayer(i)
+	cards := []Card{}
+	for c, v := range p.hand {
+		for j := 0; j < v; j++ {
+			cards = append(cards, c)
+		}
+	}
+	for _, c := range cards {
+		g.moveCard(cardInstance{card: c, owner: i}, zoneHand, zoneLibrary)
+	}
+	p.shuffle()
+}
+
+// 400.3 If an object would go to any library, graveyard, or hand other than its owner's,
+// it goes to its owner's corresponding zone.
+func (p *player) zone(z zone) *orderedCards {
+	switch z {
+	case zoneLibrary:
+		return &p.library
+	case zoneGraveyard:
+		return &p.graveyard
+	case zoneExile:
+		return &p.exile
+	case zoneCommand:
+		return &p.command
+	}
+	return nil
+}
+
+func (p *player) removeFromZone(c Card, z zone) {
+	switch z {
+	case zoneHand:
+		p.hand[c] -= 1
+		if p.hand[c] == 0 {
+			delete(p.hand, c)
+		}
+		return
+	case zoneStack, zoneNone:
+		return
+	}
+	cards := p.zone(z)
+	for i, card := range *cards {
+		if card == c {
+			*cards = append((*cards)[:i:i], (*cards)[i+1:]...)
+			if len(*cards) == 0 {
+				*cards = nil
+			}
+			return
+		}
+	}
+	panic("card not found in zone")
+}
+
+// cards put into a library go on top unless stated otherwise
+func (p *player) addToZone(c Card, z zone, pos position) {
+	switch z {
+	case zoneHand:
+		if p.hand == nil {
+			p.hand = unorderedCards{}
+		}
+		p.hand[c] += 1
+	case zoneLibrary:
+		if pos == bottom {
+			p.library = append(p.library, c)
+			return
+		}
+		p.library = append(orderedCards{c}, p.library...)
+	case zoneStack:
+	default:
+		cards := p.zone(z)
+		*cards = append(*cards, c)
+	}
+}
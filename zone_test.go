@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveCard(t *testing.T) {
+	g := &game{
+		numPlayers: 2,
+		players: []*player{
+			SELF: &player{lifeTotal: 20, battlefield: battlefield{creatures: []cardInstance{
+				{id: 1, card: falkenrathReaver, owner: OPP, damage: 1, counters: map[counterType]int{plusOneCounter: 1}},
+			}}},
+			OPP: &player{lifeTotal: 20, hand: unorderedCards{lavaSpike: 1}, library: orderedCards{island}},
+		},
+	}
+	self, opp := g.getPlayer(SELF), g.getPlayer(OPP)
+
+	// 400.3 cards go to their owner's zones
+	g.moveCard(cardInstance{id: 1}, zoneBattlefield, zoneExile)
+	if len(self.battlefield.creatures) != 0 || len(self.exile) != 0 {
+		t.Fatalf("creature should have left its controller's battlefield")
+	}
+	if !reflect.DeepEqual(opp.exile, orderedCards{falkenrathReaver}) {
+		t.Fatalf("exile: got %v want %v", opp.exile, orderedCards{falkenrathReaver})
+	}
+
+	// 400.7 returning to the battlefield makes it a new object
+	c := g.moveCard(cardInstance{card: falkenrathReaver, owner: OPP}, zoneExile, zoneBattlefield)
+	if c.id == 0 || c.id == 1 || c.damage != 0 || c.counters != nil || c.owner != OPP {
+		t.Errorf("permanent should be a new object: got %+v", c)
+	}
+	if opp.exile != nil || len(opp.battlefield.creatures) != 1 {
+		t.Errorf("creature should have moved from exile to its owner's battlefield")
+	}
+
+	g.moveCard(cardInstance{card: lavaSpike, owner: OPP}, zoneHand, zoneLibrary)
+	if len(opp.hand) != 0 || !reflect.DeepEqual(opp.library, orderedCards{lavaSpike, island}) {
+		t.Errorf("card should be put on top of the library: hand %v library %v", opp.hand, opp.library)
+	}
+
+	opp.hand = unorderedCards{divination: 1}
+	g.moveCardTo(cardInstance{card: divination, owner: OPP}, zoneHand, zoneLibrary, bottom)
+	if len(opp.hand) != 0 || !reflect.DeepEqual(opp.library, orderedCards{lavaSpike, island, divination}) {
+		t.Errorf("card should be put on the bottom of the library: hand %v library %v", opp.hand, opp.library)
+	}
+}
+
+func TestLeavesTheBattlefieldEvent(t *testing.T) {
+	for i, tt := range []struct {
+		to   zone
+		card Card
+		want []eventType
+	}{
+		{to: zoneGraveyard, card: falkenrathReaver, want: []eventType{leavesTheBattlefield, dies}},
+		{to: zoneHand, card: falkenrathReaver, want: []eventType{leavesTheBattlefield}},
+		{to: zoneGraveyard, card: bonesplitter, want: []eventType{leavesTheBattlefield}},
+	} {
+		e := event{etype: zoneChange, card: tt.card, from: zoneBattlefield, to: tt.to}
+		got := []eventType{}
+		for _, et := range []eventType{entersTheBattlefield, leavesTheBattlefield, dies} {
+			if e.is(et) {
+				got = append(got, et)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d) got %v want %v", i, got, tt.want)
+		}
+	}
+}